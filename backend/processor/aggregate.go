@@ -0,0 +1,374 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// CareerReport é o resultado do comando aggregate: totais de carreira por SteamID
+type CareerReport struct {
+	Matches int            `json:"matches"`
+	Sources []string       `json:"sources"`
+	Failed  []BatchEntry   `json:"failed,omitempty"`
+	Players []CareerPlayer `json:"players"`
+}
+
+// CareerPlayer agrupa as partidas pelo SteamID, então trocas de nome não separam o jogador
+type CareerPlayer struct {
+	SteamID uint64   `json:"steamID"`
+	Name    string   `json:"name"` // Nome mais usado
+	Aliases []string `json:"aliases,omitempty"`
+	CareerSplit
+	Maps  map[string]*CareerSplit `json:"maps"`
+	Sides map[string]*CareerSplit `json:"sides"`
+}
+
+type CareerSplit struct {
+	Matches int     `json:"matches"`
+	Rounds  int     `json:"rounds"`
+	Kills   int     `json:"kills"`
+	Deaths  int     `json:"deaths"`
+	Assists int     `json:"assists"`
+	HSKills int     `json:"hsKills"`
+	Damage  int     `json:"damage"`
+	ADR     float64 `json:"adr"` // Damage total / rounds totais (ponderado por rounds)
+	HSRate  float64 `json:"hsRate"`
+	KDRatio float64 `json:"kdRatio"`
+	Rating  float64 `json:"rating"`
+}
+
+func (c *CareerSplit) add(rounds, kills, deaths, assists, hsKills, damage int) {
+	c.Matches++
+	c.Rounds += rounds
+	c.Kills += kills
+	c.Deaths += deaths
+	c.Assists += assists
+	c.HSKills += hsKills
+	c.Damage += damage
+}
+
+// finish calcula as taxas a partir dos totais (nunca média de médias)
+func (c *CareerSplit) finish() {
+	if c.Rounds > 0 {
+		c.ADR = float64(c.Damage) / float64(c.Rounds)
+	}
+	if c.Kills > 0 {
+		c.HSRate = (float64(c.HSKills) / float64(c.Kills)) * 100
+	}
+	if c.Deaths > 0 {
+		c.KDRatio = float64(c.Kills) / float64(c.Deaths)
+	}
+	c.Rating = simpleRating(c.Kills, c.Deaths, c.ADR)
+}
+
+func runAggregate(args []string) {
+	fs := flag.NewFlagSet("aggregate", flag.ExitOnError)
+	steamID := fs.Uint64("steamid", 0, "Steam ID64 para gerar só a carreira desse jogador")
+	outPath := fs.String("out", "", "arquivo de saída (padrão: stdout)")
+	logOpts := addLogFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Uso: %s aggregate [-steamid id] [-out arquivo] <resultado.json|demo|diretório|glob>...\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	logOpts.setup()
+
+	if fs.NArg() < 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	sources, err := findAggregateSources(fs.Args(), *outPath)
+	if err != nil {
+		slog.Error("Erro ao listar entradas", "err", err)
+		os.Exit(1)
+	}
+	if len(sources) == 0 {
+		slog.Error("Nenhum resultado ou demo encontrado")
+		os.Exit(1)
+	}
+
+	report := &CareerReport{Sources: []string{}, Players: []CareerPlayer{}}
+	analyses := loadAggregateSources(sources, report)
+
+	report.Matches = len(analyses)
+	report.Players = aggregateCareers(analyses, *steamID)
+
+	if *outPath != "" {
+		if err := writeJSONFile(*outPath, report); err != nil {
+			slog.Error("Erro ao gravar resultado", "path", *outPath, "err", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	jsonData, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		slog.Error("Erro ao serializar JSON", "err", err)
+		os.Exit(1)
+	}
+	fmt.Print(string(jsonData))
+}
+
+// errNotAnalysis marca JSONs que não são resultado de análise (relatório de carreira, configs...)
+var errNotAnalysis = errors.New("não é um resultado de análise (sem metadata/players)")
+
+// loadAggregateSources carrega as entradas e descarta partidas repetidas (a mesma demo
+// analisada de novo ou com outro nome). Falhas vão para report.Failed.
+func loadAggregateSources(sources []string, report *CareerReport) []*SimpleAnalysis {
+	analyses := []*SimpleAnalysis{}
+	seen := make(map[string]string)
+	for _, source := range sources {
+		analysis, err := loadAnalysis(source)
+		if errors.Is(err, errNotAnalysis) {
+			slog.Warn("Ignorando JSON que não é análise", "source", source)
+			continue
+		}
+		if err != nil {
+			slog.Warn("Ignorando entrada", "source", source, "err", err)
+			report.Failed = append(report.Failed, BatchEntry{Demo: source, Status: "error", Error: err.Error()})
+			continue
+		}
+		key := matchKey(analysis)
+		if first, dup := seen[key]; dup {
+			slog.Warn("Ignorando partida repetida", "source", source, "same", first)
+			continue
+		}
+		seen[key] = source
+		report.Sources = append(report.Sources, source)
+		analyses = append(analyses, analysis)
+	}
+	return analyses
+}
+
+// matchKey identifica a partida pelo conteúdo: mapa, duração, placar e participantes
+func matchKey(analysis *SimpleAnalysis) string {
+	ids := make([]uint64, 0, len(analysis.Players))
+	for _, player := range analysis.Players {
+		ids = append(ids, player.SteamID)
+	}
+	slices.Sort(ids)
+	m := analysis.Metadata
+	return fmt.Sprintf("%s|%s|%d|%d|%d|%v", m.Map, m.Duration, m.Rounds, m.ScoreT, m.ScoreCT, ids)
+}
+
+// findAggregateSources expande diretórios (JSONs de resultado e demos) e globs.
+// O arquivo de saída (exclude) nunca entra, e uma demo com o resultado ao lado
+// (partida.dem e partida.json) entra só uma vez, pelo JSON.
+func findAggregateSources(args []string, exclude string) ([]string, error) {
+	sources := []string{}
+	for _, arg := range args {
+		if st, err := os.Stat(arg); err == nil {
+			if !st.IsDir() {
+				sources = append(sources, arg)
+				continue
+			}
+			entries, err := os.ReadDir(arg)
+			if err != nil {
+				return nil, err
+			}
+			for _, entry := range entries {
+				name := entry.Name()
+				// index.json é o resumo do comando batch, não uma análise
+				if entry.IsDir() || name == "index.json" {
+					continue
+				}
+				if strings.HasSuffix(strings.ToLower(name), ".json") || isDemoFile(name) {
+					sources = append(sources, filepath.Join(arg, name))
+				}
+			}
+			continue
+		}
+
+		matches, err := filepath.Glob(arg)
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(matches)
+		sources = append(sources, matches...)
+	}
+	return dedupeSources(sources, exclude), nil
+}
+
+// dedupeSources tira o arquivo excluído, caminhos repetidos e a demo que já tem o
+// resultado (.json) no mesmo diretório
+func dedupeSources(sources []string, exclude string) []string {
+	absPath := func(path string) string {
+		if abs, err := filepath.Abs(path); err == nil {
+			return abs
+		}
+		return filepath.Clean(path)
+	}
+	excluded := ""
+	if exclude != "" {
+		excluded = absPath(exclude)
+	}
+
+	isJSON := func(path string) bool { return strings.HasSuffix(strings.ToLower(path), ".json") }
+	withResult := make(map[string]bool)
+	for _, source := range sources {
+		if isJSON(source) && absPath(source) != excluded {
+			withResult[filepath.Join(filepath.Dir(absPath(source)), demoBaseName(source))] = true
+		}
+	}
+
+	unique := []string{}
+	seen := make(map[string]bool)
+	for _, source := range sources {
+		abs := absPath(source)
+		if abs == excluded || seen[abs] {
+			continue
+		}
+		if isDemoFile(source) && withResult[filepath.Join(filepath.Dir(abs), demoBaseName(source))] {
+			slog.Debug("Demo com resultado já gerado, usando o JSON", "demo", source)
+			continue
+		}
+		seen[abs] = true
+		unique = append(unique, source)
+	}
+	return unique
+}
+
+// loadAnalysis lê um resultado já gerado (.json) ou analisa a demo
+func loadAnalysis(source string) (*SimpleAnalysis, error) {
+	if !strings.HasSuffix(strings.ToLower(source), ".json") {
+		return analyzeDemo(context.Background(), source, analyzeOptions{})
+	}
+
+	data, err := os.ReadFile(source)
+	if err != nil {
+		return nil, err
+	}
+	var probe struct {
+		Metadata json.RawMessage `json:"metadata"`
+		Players  json.RawMessage `json:"players"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, fmt.Errorf("resultado inválido: %w", err)
+	}
+	if probe.Metadata == nil || probe.Players == nil {
+		return nil, errNotAnalysis
+	}
+
+	analysis := &SimpleAnalysis{}
+	if err := json.Unmarshal(data, analysis); err != nil {
+		return nil, fmt.Errorf("resultado inválido: %w", err)
+	}
+	return analysis, nil
+}
+
+// aggregateCareers soma as partidas por SteamID; com onlySteamID != 0 devolve só esse jogador
+func aggregateCareers(analyses []*SimpleAnalysis, onlySteamID uint64) []CareerPlayer {
+	careers := make(map[uint64]*CareerPlayer)
+	nameCounts := make(map[uint64]map[string]int)
+	lastNames := make(map[uint64]string)
+
+	for _, analysis := range analyses {
+		mapName := analysis.Metadata.Map
+		if mapName == "" {
+			mapName = "unknown"
+		}
+
+		for _, player := range analysis.Players {
+			if player.SteamID == 0 || (onlySteamID != 0 && player.SteamID != onlySteamID) {
+				continue
+			}
+
+			career, exists := careers[player.SteamID]
+			if !exists {
+				career = &CareerPlayer{
+					SteamID: player.SteamID,
+					Maps:    make(map[string]*CareerSplit),
+					Sides:   make(map[string]*CareerSplit),
+				}
+				careers[player.SteamID] = career
+				nameCounts[player.SteamID] = make(map[string]int)
+			}
+			if player.Name != "" {
+				nameCounts[player.SteamID][player.Name]++
+				lastNames[player.SteamID] = player.Name
+			}
+
+			// Resultados antigos não têm rounds por jogador: usar os rounds da partida
+			rounds := player.Rounds
+			if rounds == 0 {
+				rounds = analysis.Metadata.Rounds
+			}
+
+			career.add(rounds, player.Kills, player.Deaths, player.Assists, player.HSKills, player.Damage)
+
+			mapSplit, exists := career.Maps[mapName]
+			if !exists {
+				mapSplit = &CareerSplit{}
+				career.Maps[mapName] = mapSplit
+			}
+			mapSplit.add(rounds, player.Kills, player.Deaths, player.Assists, player.HSKills, player.Damage)
+
+			for side, stats := range player.Sides {
+				if stats == nil || stats.Rounds == 0 {
+					continue
+				}
+				sideSplit, exists := career.Sides[side]
+				if !exists {
+					sideSplit = &CareerSplit{}
+					career.Sides[side] = sideSplit
+				}
+				sideSplit.add(stats.Rounds, stats.Kills, stats.Deaths, 0, stats.HSKills, stats.Damage)
+			}
+		}
+	}
+
+	players := make([]CareerPlayer, 0, len(careers))
+	for steamID, career := range careers {
+		career.Name, career.Aliases = pickCareerName(nameCounts[steamID], lastNames[steamID])
+		career.finish()
+		for _, split := range career.Maps {
+			split.finish()
+		}
+		for _, split := range career.Sides {
+			split.finish()
+		}
+		players = append(players, *career)
+	}
+
+	sort.Slice(players, func(i, j int) bool {
+		if players[i].Rounds != players[j].Rounds {
+			return players[i].Rounds > players[j].Rounds
+		}
+		return players[i].SteamID < players[j].SteamID
+	})
+	return players
+}
+
+// pickCareerName escolhe o nome mais usado (empate: o mais recente) e lista os demais como aliases
+func pickCareerName(counts map[string]int, lastName string) (string, []string) {
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	best := ""
+	for _, name := range names {
+		if best == "" || counts[name] > counts[best] || (counts[name] == counts[best] && name == lastName) {
+			best = name
+		}
+	}
+
+	aliases := []string{}
+	for _, name := range names {
+		if name != best {
+			aliases = append(aliases, name)
+		}
+	}
+	return best, aliases
+}
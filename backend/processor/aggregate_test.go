@@ -0,0 +1,66 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeAnalysis(t *testing.T, path, mapName string, steamIDs ...uint64) {
+	t.Helper()
+	analysis := &SimpleAnalysis{Metadata: MatchMetadata{Map: mapName, Duration: "40:00", Rounds: 24, ScoreT: 13, ScoreCT: 11}}
+	for _, id := range steamIDs {
+		analysis.Players = append(analysis.Players, SimplePlayer{SteamID: id, Name: "p", Kills: 10})
+	}
+	if err := writeJSONFile(path, analysis); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Rodar o aggregate duas vezes no mesmo diretório não conta o relatório anterior,
+// JSONs soltos nem a demo que já tem resultado
+func TestAggregateSourcesSkipNonAnalyses(t *testing.T) {
+	dir := t.TempDir()
+	writeAnalysis(t, filepath.Join(dir, "partida1.json"), "de_nuke", 1, 2)
+	writeAnalysis(t, filepath.Join(dir, "partida2.json"), "de_inferno", 1, 3)
+	// Mesma partida que partida1, gravada de novo com outro nome
+	writeAnalysis(t, filepath.Join(dir, "copia.json"), "de_nuke", 2, 1)
+	// Demo cujo resultado já está ao lado: não é analisada (nem é uma demo válida)
+	if err := os.WriteFile(filepath.Join(dir, "partida1.dem"), []byte("garbage"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte(`{"theme": "dark"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	careerPath := filepath.Join(dir, "career.json")
+	if err := writeJSONFile(careerPath, &CareerReport{Matches: 2, Players: []CareerPlayer{{SteamID: 1}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// -out apontando para o relatório: fica fora já na listagem
+	sources, err := findAggregateSources([]string{dir}, careerPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, source := range sources {
+		if name := filepath.Base(source); name == "career.json" || name == "partida1.dem" {
+			t.Errorf("%s não deveria estar nas entradas", name)
+		}
+	}
+
+	// Sem -out o relatório é listado, mas não é uma análise
+	sources, err = findAggregateSources([]string{dir}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := &CareerReport{}
+	analyses := loadAggregateSources(sources, report)
+	if len(analyses) != 2 || len(report.Failed) != 0 {
+		t.Fatalf("got %d partidas (%v), %d falhas, want 2 e nenhuma falha", len(analyses), report.Sources, len(report.Failed))
+	}
+
+	careers := aggregateCareers(analyses, 1)
+	if len(careers) != 1 || careers[0].Matches != 2 || careers[0].Kills != 20 {
+		t.Errorf("carreira do jogador 1: %+v", careers)
+	}
+}
@@ -0,0 +1,336 @@
+package main
+
+import (
+	"math"
+
+	common "github.com/markus-wa/demoinfocs-golang/v5/pkg/demoinfocs/common"
+)
+
+const (
+	// Intervalo máximo entre disparos da mesma arma para continuar o mesmo spray
+	sprayResetGap = 0.4
+	// Bullets a partir deste índice entram no mesmo grupo do spray
+	maxSprayBullet = 30
+	// Acima desta fração da velocidade máxima da arma o disparo perde precisão
+	accurateSpeedFraction = 0.34
+	// Janela antes do disparo em que estar rápido e parar conta como counter-strafe
+	counterStrafeWindow = 0.25
+)
+
+// Classificação do movimento no disparo
+const (
+	shotStationary     = "stationary"
+	shotCounterStrafed = "counterStrafed"
+	shotMoving         = "moving"
+)
+
+// Velocidade máxima aproximada por classe de arma (unidades/s)
+var maxSpeedByClass = map[common.EquipmentClass]float64{
+	common.EqClassPistols: 240,
+	common.EqClassSMG:     240,
+	common.EqClassHeavy:   220,
+	common.EqClassRifle:   225,
+}
+
+// AccuracyStat é a precisão de um grupo de disparos
+type AccuracyStat struct {
+	Shots    int     `json:"shots"`
+	Hits     int     `json:"hits"`
+	Accuracy float64 `json:"accuracy"` // Hits / Shots em %
+}
+
+// SprayBullet é a precisão do N-ésimo disparo de um spray (o último grupo junta 30+)
+type SprayBullet struct {
+	Bullet int `json:"bullet"`
+	AccuracyStat
+}
+
+// AimStats resume os disparos de armas de fogo de um jogador (só rounds oficiais)
+type AimStats struct {
+	AccuracyStat
+	FirstBullet AccuracyStat  `json:"firstBullet"` // Primeiro disparo de cada spray
+	Spray       []SprayBullet `json:"spray"`
+	MovingShots int           `json:"movingShots"` // Disparos acima da velocidade precisa da arma
+	MovingPct   float64       `json:"movingPct"`
+	Movement    MovementStats `json:"movement"`
+}
+
+// MovementStats separa o movimento nos disparos de rifles e pistolas
+type MovementStats struct {
+	Rifles  MovementBreakdown `json:"rifles"`
+	Pistols MovementBreakdown `json:"pistols"`
+}
+
+// MovementBreakdown conta os disparos parados, com counter-strafe e em movimento
+type MovementBreakdown struct {
+	Shots             int     `json:"shots"`
+	Stationary        int     `json:"stationary"`
+	CounterStrafed    int     `json:"counterStrafed"`
+	Moving            int     `json:"moving"`
+	StationaryPct     float64 `json:"stationaryPct"`
+	CounterStrafedPct float64 `json:"counterStrafedPct"`
+	MovingPct         float64 `json:"movingPct"`
+}
+
+// shotRecord é um WeaponFire de arma de fogo
+type shotRecord struct {
+	tick     int
+	time     float64
+	weapon   string
+	class    common.EquipmentClass
+	bullet   int // Posição no spray (1 = primeiro disparo)
+	viewX    float32
+	viewY    float32
+	position Position
+	velocity Position
+	// Maior velocidade horizontal na janela de counter-strafe antes do disparo
+	recentSpeed float64
+	hit         bool
+}
+
+// speed é a velocidade horizontal do atirador no disparo
+func (s *shotRecord) speed() float64 {
+	return math.Hypot(s.velocity.X, s.velocity.Y)
+}
+
+// moving diz se o disparo foi feito acima da velocidade precisa da arma
+func (s *shotRecord) moving() bool {
+	return s.speed() > maxSpeedByClass[s.class]*accurateSpeedFraction
+}
+
+// movement classifica o disparo: em movimento, parado depois de estar rápido logo antes
+// (counter-strafe) ou parado
+func (s *shotRecord) movement() string {
+	switch {
+	case s.moving():
+		return shotMoving
+	case s.recentSpeed > maxSpeedByClass[s.class]*accurateSpeedFraction:
+		return shotCounterStrafed
+	default:
+		return shotStationary
+	}
+}
+
+type speedSample struct {
+	time  float64
+	speed float64
+}
+
+type positionSample struct {
+	position Position
+	time     float64
+	velocity Position
+	recent   []speedSample // Velocidades dentro da janela de counter-strafe
+}
+
+// recentSpeed é a maior velocidade horizontal da janela até now
+func (s *positionSample) recentSpeed() float64 {
+	speed := 0.0
+	for _, sample := range s.recent {
+		speed = max(speed, sample.speed)
+	}
+	return speed
+}
+
+// aimTracker guarda cada disparo com ângulo de visão, posição e velocidade do atirador
+// e liga os PlayerHurt aos disparos. A v5 do parser não expõe a velocidade do jogador:
+// ela é estimada pela variação da posição entre frames.
+type aimTracker struct {
+	shots   map[uint64][]*shotRecord
+	samples map[uint64]*positionSample
+}
+
+func newAimTracker() *aimTracker {
+	return &aimTracker{
+		shots:   make(map[uint64][]*shotRecord),
+		samples: make(map[uint64]*positionSample),
+	}
+}
+
+// isGun diz se o equipamento é arma de fogo (pistola, SMG, pesada ou rifle)
+func isGun(w *common.Equipment) bool {
+	if w == nil {
+		return false
+	}
+	_, exists := maxSpeedByClass[w.Class()]
+	return exists
+}
+
+// sample atualiza posição e velocidade dos jogadores vivos; chamado a cada frame
+func (t *aimTracker) sample(players []*common.Player, now float64) {
+	for _, player := range players {
+		if player == nil {
+			continue
+		}
+		id := participantID(player)
+		// Morto perde a amostra: a posição do respawn não vira velocidade
+		if !player.IsAlive() {
+			delete(t.samples, id)
+			continue
+		}
+		pos := player.Position()
+		current := Position{X: pos.X, Y: pos.Y, Z: pos.Z}
+		last, exists := t.samples[id]
+		if !exists {
+			t.samples[id] = &positionSample{position: current, time: now}
+			continue
+		}
+		if dt := now - last.time; dt > 0 {
+			last.velocity = Position{
+				X: (current.X - last.position.X) / dt,
+				Y: (current.Y - last.position.Y) / dt,
+				Z: (current.Z - last.position.Z) / dt,
+			}
+		}
+		last.position = current
+		last.time = now
+
+		// Mantém só a janela de counter-strafe
+		last.recent = append(last.recent, speedSample{time: now, speed: math.Hypot(last.velocity.X, last.velocity.Y)})
+		drop := 0
+		for drop < len(last.recent) && now-last.recent[drop].time > counterStrafeWindow {
+			drop++
+		}
+		last.recent = last.recent[drop:]
+	}
+}
+
+// fire registra um disparo de arma de fogo
+func (t *aimTracker) fire(shooter *common.Player, weapon *common.Equipment, tick int, now float64) {
+	if shot := t.capture(shooter, weapon, tick, now); shot != nil {
+		t.record(participantID(shooter), shot)
+	}
+}
+
+// capture lê o estado do atirador no disparo (visão, posição, velocidade); nil se não é
+// arma de fogo. Separado de record para o round de faca pendente guardar o disparo.
+func (t *aimTracker) capture(shooter *common.Player, weapon *common.Equipment, tick int, now float64) *shotRecord {
+	if !isGun(weapon) {
+		return nil
+	}
+	id := participantID(shooter)
+	pos := shooter.Position()
+	shot := &shotRecord{
+		tick:     tick,
+		time:     now,
+		weapon:   weaponName(weapon),
+		class:    weapon.Class(),
+		bullet:   1,
+		viewX:    shooter.ViewDirectionX(),
+		viewY:    shooter.ViewDirectionY(),
+		position: Position{X: pos.X, Y: pos.Y, Z: pos.Z},
+	}
+	if sample, exists := t.samples[id]; exists {
+		shot.velocity = sample.velocity
+		shot.recentSpeed = sample.recentSpeed()
+	}
+	return shot
+}
+
+// record guarda o disparo e calcula a posição dele no spray
+func (t *aimTracker) record(id uint64, shot *shotRecord) {
+	if shots := t.shots[id]; len(shots) > 0 {
+		last := shots[len(shots)-1]
+		if last.weapon == shot.weapon && shot.time-last.time <= sprayResetGap {
+			shot.bullet = last.bullet + 1
+		}
+	}
+	t.shots[id] = append(t.shots[id], shot)
+}
+
+// hit marca como acerto o último disparo do atacante se o dano veio dele (mesmo tick ou o seguinte)
+func (t *aimTracker) hit(attacker *common.Player, weapon *common.Equipment, tick int) {
+	if !isGun(weapon) {
+		return
+	}
+	shots := t.shots[participantID(attacker)]
+	if len(shots) == 0 {
+		return
+	}
+	last := shots[len(shots)-1]
+	if last.weapon == weaponName(weapon) && tick-last.tick <= 1 {
+		last.hit = true
+	}
+}
+
+// stats resume os disparos do jogador; nil se ele não atirou
+func (t *aimTracker) stats(id uint64) *AimStats {
+	shots := t.shots[id]
+	if len(shots) == 0 {
+		return nil
+	}
+
+	aim := &AimStats{Spray: []SprayBullet{}}
+	spray := make(map[int]*AccuracyStat)
+	for _, shot := range shots {
+		bullet := min(shot.bullet, maxSprayBullet)
+		if spray[bullet] == nil {
+			spray[bullet] = &AccuracyStat{}
+		}
+		spray[bullet].count(shot.hit)
+		aim.count(shot.hit)
+		if shot.bullet == 1 {
+			aim.FirstBullet.count(shot.hit)
+		}
+		if shot.moving() {
+			aim.MovingShots++
+		}
+		switch shot.class {
+		case common.EqClassRifle:
+			aim.Movement.Rifles.count(shot.movement())
+		case common.EqClassPistols:
+			aim.Movement.Pistols.count(shot.movement())
+		}
+	}
+
+	aim.finish()
+	aim.FirstBullet.finish()
+	for bullet := 1; bullet <= maxSprayBullet; bullet++ {
+		if stat, exists := spray[bullet]; exists {
+			stat.finish()
+			aim.Spray = append(aim.Spray, SprayBullet{Bullet: bullet, AccuracyStat: *stat})
+		}
+	}
+	aim.MovingPct = float64(aim.MovingShots) / float64(aim.Shots) * 100
+	aim.Movement.Rifles.finish()
+	aim.Movement.Pistols.finish()
+	return aim
+}
+
+// reset descarta os disparos (restart da partida)
+func (t *aimTracker) reset() {
+	clear(t.shots)
+}
+
+func (a *AccuracyStat) count(hit bool) {
+	a.Shots++
+	if hit {
+		a.Hits++
+	}
+}
+
+func (a *AccuracyStat) finish() {
+	if a.Shots > 0 {
+		a.Accuracy = float64(a.Hits) / float64(a.Shots) * 100
+	}
+}
+
+func (m *MovementBreakdown) count(movement string) {
+	m.Shots++
+	switch movement {
+	case shotStationary:
+		m.Stationary++
+	case shotCounterStrafed:
+		m.CounterStrafed++
+	case shotMoving:
+		m.Moving++
+	}
+}
+
+func (m *MovementBreakdown) finish() {
+	if m.Shots > 0 {
+		m.StationaryPct = float64(m.Stationary) / float64(m.Shots) * 100
+		m.CounterStrafedPct = float64(m.CounterStrafed) / float64(m.Shots) * 100
+		m.MovingPct = float64(m.Moving) / float64(m.Shots) * 100
+	}
+}
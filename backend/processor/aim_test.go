@@ -0,0 +1,267 @@
+package main
+
+import (
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/golang/geo/r3"
+	common "github.com/markus-wa/demoinfocs-golang/v5/pkg/demoinfocs/common"
+	st "github.com/markus-wa/demoinfocs-golang/v5/pkg/demoinfocs/sendtables"
+)
+
+// fakePawn é o pawn de um jogador de teste: posição, ângulos de visão, vida e spotted
+type fakePawn struct {
+	fakeEntity
+	pos r3.Vector
+}
+
+func (e *fakePawn) Position() r3.Vector { return e.pos }
+
+func (e *fakePawn) PropertyValueMust(name string) st.PropertyValue {
+	v, _ := e.PropertyValue(name)
+	return v
+}
+
+func (e *fakePawn) Property(name string) st.Property {
+	return fakeProperty{value: e.props[name]}
+}
+
+// look aponta a visão: yaw e pitch em graus (pitch positivo olha para baixo)
+func (e *fakePawn) look(yaw, pitch float32) {
+	e.props["m_angEyeAngles"] = []float32{pitch, yaw, 0}
+}
+
+func (e *fakePawn) die() {
+	e.props["m_iHealth"] = int32(0)
+	e.props["m_lifeState"] = uint64(1)
+}
+
+// spottedBy marca o pawn como visto pelos jogadores dados (m_bSpottedByMask)
+func (e *fakePawn) spottedBy(players ...*common.Player) {
+	mask := uint64(0)
+	for _, p := range players {
+		mask |= 1 << uint(p.EntityID-1)
+	}
+	e.props["m_bSpottedByMask.0000"] = mask
+}
+
+type fakeProperty struct {
+	st.Property
+	value any
+}
+
+func (p fakeProperty) Value() st.PropertyValue { return st.PropertyValue{Any: p.value} }
+
+// fakeProvider liga o controller ao pawn e devolve a arma da mão
+type fakeProvider struct {
+	pawn   *fakePawn
+	weapon *common.Equipment
+}
+
+func (fakeProvider) IngameTick() int                              { return 0 }
+func (fakeProvider) TickRate() float64                            { return 64 }
+func (fakeProvider) FindPlayerByHandle(uint64) *common.Player     { return nil }
+func (fakeProvider) FindPlayerByPawnHandle(uint64) *common.Player { return nil }
+func (p fakeProvider) FindWeaponByEntityID(int) *common.Equipment { return p.weapon }
+func (p fakeProvider) FindEntityByHandle(uint64) st.Entity        { return p.pawn }
+
+func r3Vec(x, y, z float64) r3.Vector {
+	return r3.Vector{X: x, Y: y, Z: z}
+}
+
+// pawnPlayer é um jogador vivo com pawn, parado na origem olhando para +X
+func pawnPlayer(steamID uint64, team common.Team, weapon *common.Equipment) (*common.Player, *fakePawn) {
+	pawn := &fakePawn{fakeEntity: fakeEntity{props: map[string]any{
+		"m_iHealth":                         int32(100),
+		"m_lifeState":                       uint64(0),
+		"m_fFlags":                          uint64(0),
+		"m_angEyeAngles":                    []float32{0, 0, 0},
+		"m_bSpottedByMask.0000":             uint64(0),
+		"m_pWeaponServices.m_hActiveWeapon": uint64(1),
+	}}}
+	p := common.NewPlayer(fakeProvider{pawn: pawn, weapon: weapon})
+	p.SteamID64 = steamID
+	p.EntityID = int(steamID)
+	p.Name = "p"
+	p.Team = team
+	p.Entity = fakeEntity{props: map[string]any{"m_hPawn": uint64(1), "m_hPlayerPawn": uint64(1)}}
+	return p, pawn
+}
+
+// rifleShot é um disparo de AK-47 já capturado, no tick e instante dados
+func rifleShot(tick int, now float64) *shotRecord {
+	return &shotRecord{tick: tick, time: now, weapon: weaponName(ak47), class: common.EqClassRifle, bullet: 1}
+}
+
+func nearlyEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+// Spray pela mesma arma com intervalo de até 0.4s; pausa maior ou troca de arma recomeça.
+// Acertos só no disparo do mesmo tick ou do seguinte e com a mesma arma.
+func TestAimTrackerSprayKnownAnswers(t *testing.T) {
+	shooter := testPlayer(1, common.TeamTerrorists)
+	id := participantID(shooter)
+	aim := newAimTracker()
+
+	aim.record(id, rifleShot(100, 10.0))
+	aim.hit(shooter, ak47, 101) // Acerta o 1º
+	aim.record(id, rifleShot(106, 10.1))
+	aim.hit(shooter, ak47, 110) // Dano tarde demais para o 2º
+	aim.record(id, rifleShot(128, 10.45))
+	aim.hit(shooter, ak47, 128) // Acerta o 3º (0.35s depois ainda é spray)
+	aim.record(id, rifleShot(160, 10.95))
+	aim.hit(shooter, glock, 160) // Dano de outra arma não é do disparo
+	glockShot := &shotRecord{tick: 162, time: 10.98, weapon: weaponName(glock), class: common.EqClassPistols, bullet: 1}
+	aim.record(id, glockShot)
+	aim.hit(shooter, glock, 162)
+
+	bullets := []int{}
+	for _, shot := range aim.shots[id] {
+		bullets = append(bullets, shot.bullet)
+	}
+	if want := []int{1, 2, 3, 1, 1}; !slices.Equal(bullets, want) {
+		t.Fatalf("bullets = %v, want %v", bullets, want)
+	}
+
+	stats := aim.stats(id)
+	if stats.Shots != 5 || stats.Hits != 3 || !nearlyEqual(stats.Accuracy, 60) {
+		t.Errorf("total = %+v, want 5 disparos, 3 acertos, 60%%", stats.AccuracyStat)
+	}
+	if fb := stats.FirstBullet; fb.Shots != 3 || fb.Hits != 2 || !nearlyEqual(fb.Accuracy, 200.0/3) {
+		t.Errorf("primeiro disparo = %+v, want 3 disparos, 2 acertos", fb)
+	}
+	want := []SprayBullet{
+		{Bullet: 1, AccuracyStat: AccuracyStat{Shots: 3, Hits: 2, Accuracy: 200.0 / 3}},
+		{Bullet: 2, AccuracyStat: AccuracyStat{Shots: 1, Hits: 0, Accuracy: 0}},
+		{Bullet: 3, AccuracyStat: AccuracyStat{Shots: 1, Hits: 1, Accuracy: 100}},
+	}
+	if len(stats.Spray) != len(want) {
+		t.Fatalf("spray = %+v, want %+v", stats.Spray, want)
+	}
+	for i := range want {
+		got := stats.Spray[i]
+		if got.Bullet != want[i].Bullet || got.Shots != want[i].Shots || got.Hits != want[i].Hits || !nearlyEqual(got.Accuracy, want[i].Accuracy) {
+			t.Errorf("spray[%d] = %+v, want %+v", i, got, want[i])
+		}
+	}
+}
+
+// A partir do 30º disparo o spray cai no mesmo grupo
+func TestAimTrackerSprayCap(t *testing.T) {
+	shooter := testPlayer(1, common.TeamTerrorists)
+	id := participantID(shooter)
+	aim := newAimTracker()
+	for i := range 35 {
+		aim.record(id, rifleShot(100+i*6, 10+float64(i)*0.1))
+	}
+
+	stats := aim.stats(id)
+	if len(stats.Spray) != maxSprayBullet {
+		t.Fatalf("%d grupos no spray, want %d", len(stats.Spray), maxSprayBullet)
+	}
+	if last := stats.Spray[maxSprayBullet-1]; last.Bullet != maxSprayBullet || last.Shots != 6 {
+		t.Errorf("último grupo = %+v, want bullet 30 com 6 disparos", last)
+	}
+	if stats.FirstBullet.Shots != 1 {
+		t.Errorf("primeiro disparo contou %d, want 1", stats.FirstBullet.Shots)
+	}
+	if aim.stats(2) != nil {
+		t.Error("jogador sem disparos deveria ter stats nil")
+	}
+}
+
+// Classificação pela velocidade no disparo e pela maior velocidade nos 0.25s antes:
+// rifle precisa é até 225*0.34 = 76.5 u/s, pistola até 240*0.34 = 81.6 u/s
+func TestShotMovementKnownAnswers(t *testing.T) {
+	cases := []struct {
+		name     string
+		class    common.EquipmentClass
+		velocity Position
+		recent   float64
+		want     string
+		moving   bool
+	}{
+		{"rifle parado", common.EqClassRifle, Position{}, 0, shotStationary, false},
+		{"rifle andando devagar", common.EqClassRifle, Position{X: 60, Y: 40}, 72, shotStationary, false},
+		{"rifle com counter-strafe", common.EqClassRifle, Position{X: 10}, 225, shotCounterStrafed, false},
+		{"rifle correndo", common.EqClassRifle, Position{X: 60, Y: 60}, 225, shotMoving, true},
+		{"pistola a 80 u/s", common.EqClassPistols, Position{Y: 80}, 80, shotStationary, false},
+		{"pistola a 85 u/s", common.EqClassPistols, Position{Y: 85}, 85, shotMoving, true},
+		{"queda não conta", common.EqClassRifle, Position{Z: -500}, 0, shotStationary, false},
+	}
+	for _, c := range cases {
+		shot := &shotRecord{class: c.class, velocity: c.velocity, recentSpeed: c.recent}
+		if got := shot.movement(); got != c.want || shot.moving() != c.moving {
+			t.Errorf("%s: movement %s moving %v, want %s %v", c.name, got, shot.moving(), c.want, c.moving)
+		}
+	}
+}
+
+// Velocidade estimada pela posição entre frames: correr a 250 u/s e parar em 0.125s
+// é counter-strafe; disparar ainda a 250 u/s é em movimento; 0.25s parado é parado.
+// Só rifles e pistolas entram na separação de movimento.
+func TestAimTrackerMovementFromSamples(t *testing.T) {
+	shooter, pawn := pawnPlayer(1, common.TeamTerrorists, ak47)
+	id := participantID(shooter)
+	aim := newAimTracker()
+	frame := 1.0 / 64
+	now := 10.0
+	step := func(dx float64) {
+		now += frame
+		pawn.pos.X += dx
+		aim.sample([]*common.Player{shooter}, now)
+	}
+
+	// Corre a 250 u/s e atira correndo
+	aim.sample([]*common.Player{shooter}, now)
+	for range 16 {
+		step(250 * frame)
+	}
+	aim.fire(shooter, ak47, 1, now)
+	// Para por 8 frames (0.125s) e atira: ainda havia velocidade alta na janela
+	for range 8 {
+		step(0)
+	}
+	aim.fire(shooter, ak47, 2, now)
+	// Mais 16 frames parado: a corrida saiu da janela
+	for range 16 {
+		step(0)
+	}
+	aim.fire(shooter, ak47, 3, now)
+	// Pistola e SMG parados: SMG conta no total, mas não na separação
+	aim.fire(shooter, glock, 4, now+1)
+	aim.fire(shooter, common.NewEquipment(common.EqMP9), 5, now+2)
+
+	got := []string{}
+	for _, shot := range aim.shots[id] {
+		got = append(got, shot.movement())
+	}
+	want := []string{shotMoving, shotCounterStrafed, shotStationary, shotStationary, shotStationary}
+	if !slices.Equal(got, want) {
+		t.Fatalf("movement = %v, want %v", got, want)
+	}
+	if speed := aim.shots[id][0].speed(); !nearlyEqual(speed, 250) {
+		t.Errorf("velocidade no 1º disparo = %f, want 250", speed)
+	}
+
+	stats := aim.stats(id)
+	if stats.MovingShots != 1 || !nearlyEqual(stats.MovingPct, 20) {
+		t.Errorf("moving = %d (%.1f%%), want 1 (20%%)", stats.MovingShots, stats.MovingPct)
+	}
+	rifles := stats.Movement.Rifles
+	if rifles.Shots != 3 || rifles.Moving != 1 || rifles.CounterStrafed != 1 || rifles.Stationary != 1 || !nearlyEqual(rifles.CounterStrafedPct, 100.0/3) {
+		t.Errorf("rifles = %+v", rifles)
+	}
+	if pistols := stats.Movement.Pistols; pistols.Shots != 1 || pistols.Stationary != 1 || pistols.StationaryPct != 100 {
+		t.Errorf("pistolas = %+v", pistols)
+	}
+
+	// Morto perde a amostra: a posição do respawn não vira velocidade
+	pawn.die()
+	aim.sample([]*common.Player{shooter}, now+3)
+	if _, exists := aim.samples[id]; exists {
+		t.Error("amostra de jogador morto não foi descartada")
+	}
+}
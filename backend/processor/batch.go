@@ -0,0 +1,265 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Extensões aceitas como demo (as comprimidas são abertas por openDemo)
+var demoExtensions = []string{".dem", ".dem.gz", ".dem.bz2", ".dem.zst"}
+
+// BatchIndex é o arquivo index.json gerado pelo comando batch
+type BatchIndex struct {
+	Total    int          `json:"total"`
+	OK       int          `json:"ok"`
+	Partial  int          `json:"partial"`
+	Failed   int          `json:"failed"`
+	Duration string       `json:"duration"`
+	Demos    []BatchEntry `json:"demos"`
+}
+
+type BatchEntry struct {
+	Demo     string `json:"demo"`
+	Output   string `json:"output,omitempty"`
+	Status   string `json:"status"` // "ok", "partial" ou "error"
+	Error    string `json:"error,omitempty"`
+	Map      string `json:"map,omitempty"`
+	Rounds   int    `json:"rounds,omitempty"`
+	ScoreT   int    `json:"scoreT,omitempty"`
+	ScoreCT  int    `json:"scoreCT,omitempty"`
+	Duration string `json:"duration,omitempty"`
+	Warnings int    `json:"warnings,omitempty"` // Avisos do parser (metadata.diagnostics)
+}
+
+func runBatch(args []string) {
+	fs := flag.NewFlagSet("batch", flag.ExitOnError)
+	outDir := fs.String("out", "batch-output", "diretório onde os resultados e o index.json são gravados")
+	workers := fs.Int("workers", defaultBatchWorkers(), "número de demos analisadas em paralelo")
+	timeout := fs.Duration("timeout", 0, "tempo máximo por demo; ao estourar a demo fica como parcial")
+	logOpts := addLogFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Uso: %s batch [-out dir] [-workers n] [-timeout d] <diretório|glob>\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	logOpts.setup()
+
+	if fs.NArg() < 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	demos, err := findDemos(fs.Arg(0))
+	if err != nil {
+		slog.Error("Erro ao listar demos", "pattern", fs.Arg(0), "err", err)
+		os.Exit(1)
+	}
+	if len(demos) == 0 {
+		slog.Error("Nenhuma demo encontrada", "pattern", fs.Arg(0))
+		os.Exit(1)
+	}
+
+	if err := os.MkdirAll(*outDir, 0o755); err != nil {
+		slog.Error("Erro ao criar diretório de saída", "dir", *outDir, "err", err)
+		os.Exit(1)
+	}
+
+	index := processBatch(demos, *outDir, *workers, *timeout)
+
+	indexPath := filepath.Join(*outDir, "index.json")
+	if err := writeJSONFile(indexPath, index); err != nil {
+		slog.Error("Erro ao gravar index", "path", indexPath, "err", err)
+		os.Exit(1)
+	}
+
+	slog.Info("Lote concluído", "duration", index.Duration, "ok", index.OK,
+		"partial", index.Partial, "failed", index.Failed, "index", indexPath)
+	if index.Failed > 0 {
+		os.Exit(exitCodePartial)
+	}
+}
+
+func defaultBatchWorkers() int {
+	// Cada parse segura a partida inteira em memória: limitar mesmo em máquinas grandes
+	return min(runtime.NumCPU(), 4)
+}
+
+// findDemos aceita um diretório (todas as demos dentro dele) ou um glob
+func findDemos(pattern string) ([]string, error) {
+	if st, err := os.Stat(pattern); err == nil && st.IsDir() {
+		entries, err := os.ReadDir(pattern)
+		if err != nil {
+			return nil, err
+		}
+		demos := []string{}
+		for _, entry := range entries {
+			if !entry.IsDir() && isDemoFile(entry.Name()) {
+				demos = append(demos, filepath.Join(pattern, entry.Name()))
+			}
+		}
+		return demos, nil
+	}
+
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(matches)
+	return matches, nil
+}
+
+func isDemoFile(name string) bool {
+	nameLower := strings.ToLower(name)
+	for _, ext := range demoExtensions {
+		if strings.HasSuffix(nameLower, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// demoBaseName remove o diretório e a extensão (.dem, .dem.gz, ...) do caminho
+func demoBaseName(demoPath string) string {
+	name := filepath.Base(demoPath)
+	nameLower := strings.ToLower(name)
+	for _, ext := range demoExtensions {
+		if strings.HasSuffix(nameLower, ext) {
+			return name[:len(name)-len(ext)]
+		}
+	}
+	return strings.TrimSuffix(name, filepath.Ext(name))
+}
+
+// processBatch analisa as demos com um pool de workers limitado.
+// Falhas de uma demo ficam registradas no index sem abortar as demais.
+func processBatch(demos []string, outDir string, workers int, timeout time.Duration) *BatchIndex {
+	startTime := time.Now()
+	workers = max(workers, 1)
+
+	// Nomes de saída resolvidos antes para evitar colisão entre demos com o mesmo nome
+	outputs := make([]string, len(demos))
+	usedNames := make(map[string]int)
+	for i, demo := range demos {
+		name := demoBaseName(demo)
+		usedNames[name]++
+		if usedNames[name] > 1 {
+			name = fmt.Sprintf("%s-%d", name, usedNames[name])
+		}
+		outputs[i] = filepath.Join(outDir, name+".json")
+	}
+
+	entries := make([]BatchEntry, len(demos))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	done := 0
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				demoStart := time.Now()
+				entry := analyzeBatchDemo(demos[i], outputs[i], timeout)
+				entries[i] = entry
+
+				mu.Lock()
+				done++
+				level := slog.LevelInfo
+				if entry.Status != "ok" {
+					level = slog.LevelWarn
+				}
+				slog.Log(context.Background(), level, "Demo processada", "n", done, "total", len(demos),
+					"demo", demos[i], "duration", formatDuration(time.Since(demoStart)),
+					"status", entry.Status, "error", entry.Error)
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for i := range demos {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	index := &BatchIndex{
+		Total:    len(demos),
+		Duration: formatDuration(time.Since(startTime)),
+		Demos:    entries,
+	}
+	for _, entry := range entries {
+		switch entry.Status {
+		case "ok":
+			index.OK++
+		case "partial":
+			index.Partial++
+		default:
+			index.Failed++
+		}
+	}
+	return index
+}
+
+// analyzeBatchDemo analisa uma demo e grava o resultado; panics viram falha da demo
+func analyzeBatchDemo(demoPath, outputPath string, timeout time.Duration) (entry BatchEntry) {
+	entry = BatchEntry{Demo: demoPath, Status: "error"}
+
+	defer func() {
+		if r := recover(); r != nil {
+			entry = BatchEntry{Demo: demoPath, Status: "error", Error: fmt.Sprintf("panic: %v", r)}
+		}
+	}()
+
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeoutCause(ctx, timeout, errTimeout)
+		defer cancel()
+	}
+
+	analysis, err := analyzeDemo(ctx, demoPath, analyzeOptions{})
+	if err != nil {
+		entry.Error = err.Error()
+		return entry
+	}
+
+	if err := writeJSONFile(outputPath, analysis); err != nil {
+		entry.Error = fmt.Sprintf("erro ao gravar resultado: %v", err)
+		return entry
+	}
+
+	entry.Output = outputPath
+	entry.Status = "ok"
+	if analysis.Metadata.Truncated {
+		entry.Status = "partial"
+		entry.Error = analysis.Metadata.Error
+	}
+	entry.Map = analysis.Metadata.Map
+	entry.Rounds = analysis.Metadata.Rounds
+	entry.ScoreT = analysis.Metadata.ScoreT
+	entry.ScoreCT = analysis.Metadata.ScoreCT
+	entry.Duration = analysis.Metadata.Duration
+	if analysis.Metadata.Diagnostics != nil {
+		entry.Warnings = analysis.Metadata.Diagnostics.Total
+	}
+	return entry
+}
+
+func writeJSONFile(path string, v any) error {
+	jsonData, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, jsonData, 0o644)
+}
@@ -7,7 +7,7 @@ echo 📦 Baixando dependências Go...
 go mod download
 
 echo 🔨 Compilando processador...
-go build -o demo-processor.exe main.go
+go build -o demo-processor.exe .
 
 if %ERRORLEVEL% EQU 0 (
     echo ✅ Compilação concluída! Binário: demo-processor.exe
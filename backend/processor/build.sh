@@ -7,7 +7,7 @@ echo "📦 Baixando dependências Go..."
 go mod download
 
 echo "🔨 Compilando processador..."
-go build -o demo-processor main.go
+go build -o demo-processor .
 
 if [ $? -eq 0 ]; then
     echo "✅ Compilação concluída! Binário: demo-processor"
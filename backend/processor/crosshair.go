@@ -0,0 +1,81 @@
+package main
+
+import (
+	"math"
+
+	common "github.com/markus-wa/demoinfocs-golang/v5/pkg/demoinfocs/common"
+)
+
+const (
+	// Altura dos olhos/cabeça acima da posição (que é a dos pés)
+	eyeHeightStanding = 64.0
+	eyeHeightDucking  = 46.0
+	// Intervalo mínimo entre amostras da convergência da mira
+	crosshairSampleInterval = 0.05
+	// Erro a partir do qual a nota de posicionamento de mira é 0
+	crosshairZeroScoreDeg = 30.0
+)
+
+// CrosshairStats resume o erro de mira no momento em que o jogador avista um inimigo.
+// Score vai de 100 (mira já na cabeça) a 0 (30° ou mais de erro mediano).
+type CrosshairStats struct {
+	Engagements        int     `json:"engagements"`
+	MedianErrorDeg     float64 `json:"medianErrorDeg"`     // Ao avistar
+	MedianShotErrorDeg float64 `json:"medianShotErrorDeg"` // No primeiro disparo
+	Score              float64 `json:"score"`
+}
+
+// CrosshairEngagement é a mira de um jogador em um contato, do avistamento até o primeiro disparo
+type CrosshairEngagement struct {
+	Round        int               `json:"round"`
+	Tick         int               `json:"tick"` // Tick do avistamento
+	SteamID      uint64            `json:"steamID"`
+	EnemySteamID uint64            `json:"enemySteamID"`
+	ErrorDeg     float64           `json:"errorDeg"`               // Ao avistar
+	ShotErrorDeg *float64          `json:"shotErrorDeg,omitempty"` // No primeiro disparo
+	TimeToShotMs *float64          `json:"timeToShotMs,omitempty"` // Avistou -> primeiro disparo
+	Samples      []CrosshairSample `json:"samples"`
+}
+
+// CrosshairSample é o erro de mira em um instante desde o avistamento
+type CrosshairSample struct {
+	TimeMs   float64 `json:"timeMs"`
+	ErrorDeg float64 `json:"errorDeg"`
+}
+
+// eyePosition estima a posição dos olhos (a v5 do parser só expõe a posição dos pés)
+func eyePosition(p *common.Player) (x, y, z float64) {
+	pos := p.Position()
+	height := eyeHeightStanding
+	if p.IsDucking() {
+		height = eyeHeightDucking
+	}
+	return pos.X, pos.Y, pos.Z + height
+}
+
+// crosshairError é o ângulo, em graus, entre a direção da visão do jogador e a cabeça do inimigo
+func crosshairError(player, enemy *common.Player) float64 {
+	yaw := float64(player.ViewDirectionX()) * math.Pi / 180
+	pitch := float64(player.ViewDirectionY())
+	if pitch > 180 {
+		pitch -= 360
+	}
+	pitch *= math.Pi / 180
+	// Pitch positivo olha para baixo
+	viewX, viewY, viewZ := math.Cos(pitch)*math.Cos(yaw), math.Cos(pitch)*math.Sin(yaw), -math.Sin(pitch)
+
+	px, py, pz := eyePosition(player)
+	ex, ey, ez := eyePosition(enemy)
+	dx, dy, dz := ex-px, ey-py, ez-pz
+	dist := math.Sqrt(dx*dx + dy*dy + dz*dz)
+	if dist == 0 {
+		return 0
+	}
+	cos := (viewX*dx + viewY*dy + viewZ*dz) / dist
+	return math.Acos(max(-1, min(1, cos))) * 180 / math.Pi
+}
+
+// crosshairScore converte o erro mediano em nota de 0 a 100
+func crosshairScore(medianErrorDeg float64) float64 {
+	return max(0, 100*(1-medianErrorDeg/crosshairZeroScoreDeg))
+}
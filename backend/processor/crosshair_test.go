@@ -0,0 +1,101 @@
+package main
+
+import (
+	"math"
+	"testing"
+
+	common "github.com/markus-wa/demoinfocs-golang/v5/pkg/demoinfocs/common"
+)
+
+// Ângulo entre a visão e a cabeça do inimigo, com a altura dos olhos em pé e agachado
+func TestCrosshairErrorKnownAnswers(t *testing.T) {
+	cases := []struct {
+		name       string
+		yaw, pitch float32
+		enemy      [3]float64
+		ducking    bool
+		want       float64
+	}{
+		{"mira na cabeça", 0, 0, [3]float64{1000, 0, 0}, false, 0},
+		{"inimigo a 90° no yaw", 0, 0, [3]float64{0, 1000, 0}, false, 90},
+		{"inimigo atrás", 90, 0, [3]float64{0, -1000, 0}, false, 180},
+		{"olhando 45° para baixo", 0, 45, [3]float64{100, 0, -100}, false, 0},
+		{"pitch 315 é 45° para cima", 0, 315, [3]float64{100, 0, 100}, false, 0},
+		{"inimigo agachado", 0, 0, [3]float64{1000, 0, 0}, true, math.Atan(18.0/1000) * 180 / math.Pi},
+	}
+	for _, c := range cases {
+		player, playerPawn := pawnPlayer(1, common.TeamTerrorists, ak47)
+		enemy, enemyPawn := pawnPlayer(2, common.TeamCounterTerrorists, ak47)
+		playerPawn.look(c.yaw, c.pitch)
+		enemyPawn.pos = r3Vec(c.enemy[0], c.enemy[1], c.enemy[2])
+		if c.ducking {
+			enemyPawn.props["m_fFlags"] = uint64(2) // FL_DUCKING
+		}
+		if got := crosshairError(player, enemy); math.Abs(got-c.want) > 1e-4 {
+			t.Errorf("%s: erro %.5f°, want %.5f°", c.name, got, c.want)
+		}
+	}
+}
+
+func TestCrosshairScore(t *testing.T) {
+	for medianError, want := range map[float64]float64{0: 100, 7.5: 75, 15: 50, 30: 0, 45: 0} {
+		if got := crosshairScore(medianError); got != want {
+			t.Errorf("crosshairScore(%v) = %v, want %v", medianError, got, want)
+		}
+	}
+}
+
+// Convergência da mira: amostras a cada crosshairSampleInterval até o primeiro disparo;
+// o erro no disparo é o do alvo escolhido
+func TestCrosshairConvergence(t *testing.T) {
+	player, playerPawn := pawnPlayer(1, common.TeamTerrorists, ak47)
+	near, nearPawn := pawnPlayer(2, common.TeamCounterTerrorists, ak47)
+	far, farPawn := pawnPlayer(3, common.TeamCounterTerrorists, ak47)
+	nearPawn.pos = r3Vec(0, 1000, 0) // Avistado a 90°
+	farPawn.pos = r3Vec(-1000, 0, 0) // Avistado depois, a 90° da mira final, e nunca mirado
+	nearPawn.spottedBy(player)
+	players := []*common.Player{player, near}
+	tracker := newEngagementTracker()
+
+	// Vira 30° por amostra até mirar em near e atira em 0.1875s
+	tracker.observe(players, 2, 20.0, 1280)
+	for i, yaw := range []float32{30, 60, 90} {
+		playerPawn.look(yaw, 0)
+		tracker.observe(players, 2, 20+0.0625*float64(i+1), 1284+4*i)
+	}
+	tracker.shot(player, ak47, 20.1875)
+	// Depois do disparo não há mais amostras
+	tracker.observe(players, 2, 20.25, 1296)
+
+	farPawn.spottedBy(player)
+	players = append(players, far)
+	tracker.observe(players, 2, 20.3125, 1300)
+	tracker.closeAll()
+
+	list := tracker.crosshairEngagements()
+	if len(list) != 2 || list[0].EnemySteamID != 2 || list[1].EnemySteamID != 3 {
+		t.Fatalf("contatos = %+v, want near (tick 1280) e far (tick 1300)", list)
+	}
+	first := list[0]
+	wantSamples := []float64{90, 60, 30, 0}
+	if len(first.Samples) != len(wantSamples) {
+		t.Fatalf("amostras = %+v, want erros %v", first.Samples, wantSamples)
+	}
+	for i, sample := range first.Samples {
+		if math.Abs(sample.ErrorDeg-wantSamples[i]) > 1e-4 {
+			t.Errorf("amostra %d = %+v, want erro %v", i, sample, wantSamples[i])
+		}
+	}
+	if first.ShotErrorDeg == nil || math.Abs(*first.ShotErrorDeg) > 1e-4 || first.TimeToShotMs == nil || *first.TimeToShotMs != 187.5 {
+		t.Errorf("disparo: erro %v tempo %v, want 0° em 187.5ms", first.ShotErrorDeg, first.TimeToShotMs)
+	}
+	second := list[1]
+	if math.Abs(second.ErrorDeg-90) > 1e-4 || second.ShotErrorDeg != nil || len(second.Samples) != 1 {
+		t.Errorf("far sem disparo = %+v, want 90° e sem disparo", second)
+	}
+
+	stats := tracker.crosshair(1)
+	if stats.Engagements != 2 || math.Abs(stats.MedianErrorDeg-90) > 1e-4 || math.Abs(stats.MedianShotErrorDeg) > 1e-4 || math.Abs(stats.Score) > 1e-3 {
+		t.Errorf("crosshair = %+v, want 2 contatos, 90° ao avistar, 0° no disparo, nota 0", *stats)
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	events "github.com/markus-wa/demoinfocs-golang/v5/pkg/demoinfocs/events"
+)
+
+// Máximo de avisos guardados com mensagem; os demais só entram na contagem
+const maxDiagnosticSamples = 20
+
+// ParserDiagnostics resume os avisos não fatais do parser (events.ParserWarn).
+// Muitos avisos indicam que a própria demo está danificada e os números podem estar errados.
+type ParserDiagnostics struct {
+	Total   int                `json:"total"`
+	ByKind  map[string]int     `json:"byKind"`
+	Samples []ParserDiagnostic `json:"samples"` // Primeiros avisos, em ordem
+}
+
+type ParserDiagnostic struct {
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+	Round   int    `json:"round"`
+	Tick    int    `json:"tick"`
+}
+
+// Nomes estáveis para os tipos de aviso do demoinfocs (o enum é só um int)
+var warnKinds = map[events.WarnType]string{
+	events.WarnTypeUndefined:                     "undefined",
+	events.WarnTypeBombsiteUnknown:               "bombsite_unknown",
+	events.WarnTypeTeamSwapPlayerNil:             "team_swap_player_nil",
+	events.WarnTypeGameEventBeforeDescriptors:    "game_event_before_descriptors",
+	events.WarnTypeUnknownDemoCommandMessageType: "unknown_demo_command",
+	events.WarnTypeUnknownEquipmentIndex:         "unknown_equipment_index",
+	events.WarnTypeMissingItemDefinitionIndex:    "missing_item_definition_index",
+	events.WarnTypeStringTableParsingFailure:     "string_table_parsing_failure",
+	events.WarnTypePacketEntitiesPanic:           "packet_entities_panic",
+	events.WarnTypeUnknownProtobufMessage:        "unknown_protobuf_message",
+}
+
+func warnKind(t events.WarnType) string {
+	if kind, ok := warnKinds[t]; ok {
+		return kind
+	}
+	return "unknown"
+}
+
+func newParserDiagnostics() *ParserDiagnostics {
+	return &ParserDiagnostics{ByKind: make(map[string]int), Samples: []ParserDiagnostic{}}
+}
+
+func (d *ParserDiagnostics) add(e events.ParserWarn, round, tick int) {
+	kind := warnKind(e.Type)
+	d.Total++
+	d.ByKind[kind]++
+	if len(d.Samples) < maxDiagnosticSamples {
+		d.Samples = append(d.Samples, ParserDiagnostic{Kind: kind, Message: e.Message, Round: round, Tick: tick})
+	}
+}
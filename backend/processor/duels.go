@@ -0,0 +1,286 @@
+package main
+
+import (
+	"math"
+	"sort"
+
+	common "github.com/markus-wa/demoinfocs-golang/v5/pkg/demoinfocs/common"
+)
+
+const (
+	// Tempo sem dano entre os dois que encerra o duelo
+	duelGap = 3.0
+	// Limites das faixas de distância (unidades do jogo)
+	duelCloseDistance  = 500.0
+	duelMediumDistance = 1200.0
+)
+
+// Duel é uma troca de dano entre dois inimigos, próxima no tempo, até um morrer ou os dois pararem
+type Duel struct {
+	Round     int        `json:"round"`
+	StartTick int        `json:"startTick"`
+	EndTick   int        `json:"endTick"`
+	Initiator uint64     `json:"initiator"`        // Quem causou o primeiro dano
+	Winner    uint64     `json:"winner,omitempty"` // Quem matou o outro (0 = ninguém morreu no duelo)
+	Distance  float64    `json:"distance"`         // No primeiro dano
+	Range     string     `json:"range"`            // "close", "medium" ou "long"
+	Players   []DuelSide `json:"players"`          // Iniciador primeiro
+
+	lastEvent float64
+	dealt     [2]bool // Se cada lado já causou dano
+}
+
+// DuelSide é um dos lados do duelo
+type DuelSide struct {
+	SteamID     uint64 `json:"steamID"`
+	Damage      int    `json:"damage"`
+	Weapon      string `json:"weapon"` // Primeira arma com que causou dano (ou a da mão, se não causou)
+	WeaponClass string `json:"weaponClass"`
+	Blind       bool   `json:"blind"` // Cego por flash no início do duelo
+}
+
+// DuelStats resume os duelos de um jogador; as taxas contam só duelos decididos
+type DuelStats struct {
+	Duels      int          `json:"duels"`
+	Decided    int          `json:"decided"` // Com morte de um dos dois
+	Won        int          `json:"won"`
+	WinRate    float64      `json:"winRate"` // Won / Decided em %
+	ByWeapon   []DuelBucket `json:"byWeapon"`
+	ByDistance []DuelBucket `json:"byDistance"`
+}
+
+// DuelBucket é a taxa de vitória de um grupo (classe de arma ou faixa de distância)
+type DuelBucket struct {
+	Key     string  `json:"key"`
+	Decided int     `json:"decided"`
+	Won     int     `json:"won"`
+	WinRate float64 `json:"winRate"`
+}
+
+// duelTracker agrupa PlayerHurt e Kill entre dois inimigos em duelos
+type duelTracker struct {
+	open map[[2]uint64]*Duel
+	done []Duel
+}
+
+func newDuelTracker() *duelTracker {
+	return &duelTracker{open: make(map[[2]uint64]*Duel)}
+}
+
+// duelKey independe da ordem dos jogadores
+func duelKey(a, b uint64) [2]uint64 {
+	if a > b {
+		a, b = b, a
+	}
+	return [2]uint64{a, b}
+}
+
+// weaponClass agrupa as armas para as taxas de vitória
+func weaponClass(w *common.Equipment) string {
+	if w == nil {
+		return "other"
+	}
+	switch w.Type {
+	case common.EqAWP, common.EqSSG08, common.EqScar20, common.EqG3SG1:
+		return "sniper"
+	case common.EqKnife:
+		return "knife"
+	}
+	switch w.Class() {
+	case common.EqClassPistols:
+		return "pistol"
+	case common.EqClassSMG:
+		return "smg"
+	case common.EqClassHeavy:
+		return "heavy"
+	case common.EqClassRifle:
+		return "rifle"
+	case common.EqClassGrenade:
+		return "grenade"
+	}
+	return "other"
+}
+
+// distanceRange classifica a distância do duelo
+func distanceRange(distance float64) string {
+	switch {
+	case distance < duelCloseDistance:
+		return "close"
+	case distance < duelMediumDistance:
+		return "medium"
+	default:
+		return "long"
+	}
+}
+
+// hurt registra dano de attacker em victim (inimigos), abrindo o duelo se preciso
+func (t *duelTracker) hurt(attacker, victim *common.Player, weapon *common.Equipment, damage, round, tick int, now float64) {
+	t.expire(now)
+	key := duelKey(participantID(attacker), participantID(victim))
+	duel, exists := t.open[key]
+	if !exists {
+		duel = openDuel(attacker, victim, round, tick)
+		t.open[key] = duel
+	}
+
+	i := 0
+	if duel.Players[1].SteamID == participantID(attacker) {
+		i = 1
+	}
+	duel.Players[i].Damage += damage
+	if !duel.dealt[i] {
+		duel.Players[i].Weapon = weaponName(weapon)
+		duel.Players[i].WeaponClass = weaponClass(weapon)
+		duel.dealt[i] = true
+	}
+	duel.lastEvent = now
+	duel.EndTick = tick
+}
+
+// openDuel começa um duelo no primeiro dano de attacker em victim
+func openDuel(attacker, victim *common.Player, round, tick int) *Duel {
+	a, b := attacker.Position(), victim.Position()
+	distance := math.Sqrt((a.X-b.X)*(a.X-b.X) + (a.Y-b.Y)*(a.Y-b.Y) + (a.Z-b.Z)*(a.Z-b.Z))
+	return &Duel{
+		Round:     round,
+		StartTick: tick,
+		EndTick:   tick,
+		Initiator: participantID(attacker),
+		Distance:  distance,
+		Range:     distanceRange(distance),
+		Players: []DuelSide{
+			{SteamID: participantID(attacker), Blind: attacker.IsBlinded()},
+			{
+				SteamID:     participantID(victim),
+				Blind:       victim.IsBlinded(),
+				Weapon:      weaponName(victim.ActiveWeapon()),
+				WeaponClass: weaponClass(victim.ActiveWeapon()),
+			},
+		},
+	}
+}
+
+// kill fecha o duelo entre killer e victim com vencedor e os outros duelos da vítima sem vencedor
+func (t *duelTracker) kill(killer, victim *common.Player, tick int, now float64) {
+	t.expire(now)
+	if victim == nil {
+		return
+	}
+	victimID := participantID(victim)
+	if killer != nil && isEnemy(killer, victim) {
+		if duel, exists := t.open[duelKey(participantID(killer), victimID)]; exists {
+			duel.Winner = participantID(killer)
+			duel.EndTick = tick
+		}
+	}
+	for key := range t.open {
+		if key[0] == victimID || key[1] == victimID {
+			t.close(key)
+		}
+	}
+}
+
+// expire fecha os duelos sem dano há mais de duelGap
+func (t *duelTracker) expire(now float64) {
+	for key, duel := range t.open {
+		if now-duel.lastEvent > duelGap {
+			t.close(key)
+		}
+	}
+}
+
+func (t *duelTracker) close(key [2]uint64) {
+	t.done = append(t.done, *t.open[key])
+	delete(t.open, key)
+}
+
+// closeAll fecha os duelos abertos (fim de round)
+func (t *duelTracker) closeAll() {
+	for key := range t.open {
+		t.close(key)
+	}
+}
+
+// reset descarta os duelos (restart da partida)
+func (t *duelTracker) reset() {
+	clear(t.open)
+	t.done = nil
+}
+
+// duels lista os duelos em ordem de início
+func (t *duelTracker) duels() []Duel {
+	list := append([]Duel{}, t.done...)
+	sort.SliceStable(list, func(i, j int) bool { return list[i].StartTick < list[j].StartTick })
+	return list
+}
+
+// stats resume os duelos do jogador por classe de arma e faixa de distância; nil sem duelos
+func (t *duelTracker) stats(id uint64) *DuelStats {
+	stats := &DuelStats{}
+	byWeapon := make(map[string]*DuelBucket)
+	byDistance := make(map[string]*DuelBucket)
+	for _, duel := range t.done {
+		var side *DuelSide
+		for i := range duel.Players {
+			if duel.Players[i].SteamID == id {
+				side = &duel.Players[i]
+			}
+		}
+		if side == nil {
+			continue
+		}
+		stats.Duels++
+		if duel.Winner == 0 {
+			continue
+		}
+		won := duel.Winner == id
+		stats.Decided++
+		if won {
+			stats.Won++
+		}
+		countDuel(byWeapon, side.WeaponClass, won)
+		countDuel(byDistance, duel.Range, won)
+	}
+	if stats.Duels == 0 {
+		return nil
+	}
+	stats.WinRate = winRate(stats.Won, stats.Decided)
+	stats.ByWeapon = sortedBuckets(byWeapon)
+	stats.ByDistance = sortedBuckets(byDistance)
+	return stats
+}
+
+func countDuel(buckets map[string]*DuelBucket, key string, won bool) {
+	bucket, exists := buckets[key]
+	if !exists {
+		bucket = &DuelBucket{Key: key}
+		buckets[key] = bucket
+	}
+	bucket.Decided++
+	if won {
+		bucket.Won++
+	}
+}
+
+// sortedBuckets lista os grupos por número de duelos decididos
+func sortedBuckets(buckets map[string]*DuelBucket) []DuelBucket {
+	list := make([]DuelBucket, 0, len(buckets))
+	for _, bucket := range buckets {
+		bucket.WinRate = winRate(bucket.Won, bucket.Decided)
+		list = append(list, *bucket)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Decided != list[j].Decided {
+			return list[i].Decided > list[j].Decided
+		}
+		return list[i].Key < list[j].Key
+	})
+	return list
+}
+
+func winRate(won, decided int) float64 {
+	if decided == 0 {
+		return 0
+	}
+	return float64(won) / float64(decided) * 100
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"testing"
+
+	common "github.com/markus-wa/demoinfocs-golang/v5/pkg/demoinfocs/common"
+)
+
+var awp = common.NewEquipment(common.EqAWP)
+
+// Duelos com números conhecidos: dano exatamente duelGap depois continua o duelo, mais que
+// isso abre outro; as taxas por arma e distância contam só duelos decididos
+func TestDuelTrackerKnownAnswers(t *testing.T) {
+	a, _ := pawnPlayer(1, common.TeamTerrorists, ak47)
+	b, bPawn := pawnPlayer(2, common.TeamCounterTerrorists, awp)
+	c, cPawn := pawnPlayer(3, common.TeamCounterTerrorists, ak47)
+	d, _ := pawnPlayer(4, common.TeamTerrorists, glock)
+	e, ePawn := pawnPlayer(5, common.TeamCounterTerrorists, ak47)
+	bPawn.pos = r3Vec(400, 0, 0)
+	cPawn.pos = r3Vec(0, 1500, 0)
+	ePawn.pos = r3Vec(800, 0, 0)
+	duels := newDuelTracker()
+
+	// Round 1: a abre em b (perto), b responde 3s depois de AWP e mata
+	duels.hurt(a, b, ak47, 27, 1, 640, 10.0)
+	duels.hurt(b, a, awp, 85, 1, 832, 13.0)
+	duels.kill(b, a, 864, 13.5)
+
+	// Round 2: d acerta c (longe), para por 3.5s e volta: dois duelos; o segundo d vence
+	duels.hurt(d, c, glock, 20, 2, 1280, 20.0)
+	duels.hurt(d, c, glock, 35, 2, 1504, 23.5)
+	duels.kill(d, c, 1536, 24.0)
+	// e acerta d (média distância) e ninguém morre até o fim do round
+	duels.hurt(e, d, ak47, 36, 2, 1600, 25.0)
+	duels.closeAll()
+
+	list := duels.duels()
+	if len(list) != 4 {
+		t.Fatalf("%d duelos, want 4: %+v", len(list), list)
+	}
+	want := []struct {
+		initiator, winner uint64
+		start, end        int
+		rangeKey          string
+		damage            [2]int
+		weapons           [2]string
+	}{
+		{1, 2, 640, 864, "close", [2]int{27, 85}, [2]string{"rifle", "sniper"}},
+		{4, 0, 1280, 1280, "long", [2]int{20, 0}, [2]string{"pistol", "rifle"}},
+		{4, 4, 1504, 1536, "long", [2]int{35, 0}, [2]string{"pistol", "rifle"}},
+		{5, 0, 1600, 1600, "medium", [2]int{36, 0}, [2]string{"rifle", "pistol"}},
+	}
+	for i, w := range want {
+		got := list[i]
+		if got.Initiator != w.initiator || got.Winner != w.winner || got.StartTick != w.start || got.EndTick != w.end || got.Range != w.rangeKey {
+			t.Errorf("duelo %d = %+v, want %+v", i, got, w)
+			continue
+		}
+		for side := range 2 {
+			if got.Players[side].Damage != w.damage[side] || got.Players[side].WeaponClass != w.weapons[side] {
+				t.Errorf("duelo %d lado %d = %+v, want dano %d e %s", i, side, got.Players[side], w.damage[side], w.weapons[side])
+			}
+		}
+	}
+
+	// d: 3 duelos, 1 decidido (vencido) de pistola e longe
+	statsD := duels.stats(4)
+	if statsD.Duels != 3 || statsD.Decided != 1 || statsD.Won != 1 || statsD.WinRate != 100 {
+		t.Errorf("d = %+v, want 3 duelos, 1 decidido e vencido", *statsD)
+	}
+	if len(statsD.ByWeapon) != 1 || statsD.ByWeapon[0] != (DuelBucket{Key: "pistol", Decided: 1, Won: 1, WinRate: 100}) {
+		t.Errorf("d por arma = %+v", statsD.ByWeapon)
+	}
+	if len(statsD.ByDistance) != 1 || statsD.ByDistance[0] != (DuelBucket{Key: "long", Decided: 1, Won: 1, WinRate: 100}) {
+		t.Errorf("d por distância = %+v", statsD.ByDistance)
+	}
+	// a perdeu de rifle, perto; b venceu de sniper
+	statsA := duels.stats(1)
+	if statsA.Decided != 1 || statsA.Won != 0 || statsA.ByWeapon[0] != (DuelBucket{Key: "rifle", Decided: 1}) || statsA.ByDistance[0] != (DuelBucket{Key: "close", Decided: 1}) {
+		t.Errorf("a = %+v", *statsA)
+	}
+	if statsB := duels.stats(2); statsB.Won != 1 || statsB.ByWeapon[0].Key != "sniper" {
+		t.Errorf("b = %+v", *statsB)
+	}
+	if duels.stats(6) != nil {
+		t.Error("quem não duelou deveria ter stats nil")
+	}
+}
+
+// A morte fecha os outros duelos da vítima sem vencedor; kill de aliado não dá vitória
+func TestDuelTrackerKillClosesVictimDuels(t *testing.T) {
+	a, _ := pawnPlayer(1, common.TeamTerrorists, ak47)
+	b, _ := pawnPlayer(2, common.TeamCounterTerrorists, ak47)
+	c, _ := pawnPlayer(3, common.TeamCounterTerrorists, ak47)
+	duels := newDuelTracker()
+
+	duels.hurt(b, a, ak47, 30, 1, 100, 5.0)
+	duels.hurt(c, a, ak47, 30, 1, 110, 5.2)
+	duels.kill(b, a, 120, 5.4)
+	if len(duels.open) != 0 {
+		t.Fatalf("%d duelos da vítima ainda abertos", len(duels.open))
+	}
+	winners := map[uint64]uint64{}
+	for _, duel := range duels.duels() {
+		winners[duel.Initiator] = duel.Winner
+	}
+	if winners[2] != 2 || winners[3] != 0 {
+		t.Errorf("vencedores por iniciador = %v, want b vence e c sem vencedor", winners)
+	}
+
+	duels.hurt(b, c, ak47, 10, 1, 130, 6.0)
+	duels.kill(b, c, 140, 6.1)
+	if last := duels.duels()[2]; last.Winner != 0 {
+		t.Errorf("kill de aliado deu vitória: %+v", last)
+	}
+}
+
+func TestDistanceRange(t *testing.T) {
+	for distance, want := range map[float64]string{0: "close", 499.9: "close", 500: "medium", 1199: "medium", 1200: "long"} {
+		if got := distanceRange(distance); got != want {
+			t.Errorf("distanceRange(%v) = %s, want %s", distance, got, want)
+		}
+	}
+}
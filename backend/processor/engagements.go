@@ -0,0 +1,325 @@
+package main
+
+import (
+	"sort"
+
+	common "github.com/markus-wa/demoinfocs-golang/v5/pkg/demoinfocs/common"
+)
+
+const (
+	// Tempo sem ver o inimigo que encerra o contato (o spotted pisca atrás de cantos e fumaça)
+	engagementGap = 0.5
+	// Reações mais lentas que isso não são reação ao inimigo (ele estava longe, o jogador fazia outra coisa)
+	maxReactionTime = 2.0
+	// Intervalo entre frames acima do qual o tempo não é somado (freezetime, fim de round)
+	maxObserveGap = 0.5
+)
+
+// ReactionStats resume o tempo entre ver um inimigo e atirar/causar dano nele (só rounds oficiais)
+type ReactionStats struct {
+	Engagements          int     `json:"engagements"`          // Inimigos avistados
+	Reactions            int     `json:"reactions"`            // Contatos com disparo dentro do limite
+	MedianReactionMs     float64 `json:"medianReactionMs"`     // Avistou -> primeiro disparo
+	DamageEngagements    int     `json:"damageEngagements"`    // Contatos com dano dentro do limite
+	MedianTimeToDamageMs float64 `json:"medianTimeToDamageMs"` // Avistou -> primeiro dano
+}
+
+// AwarenessStats resume quanto o jogador ficou exposto e quantas mortes vieram de quem ele não via
+type AwarenessStats struct {
+	AliveSeconds  float64 `json:"aliveSeconds"` // Vivo com o round rolando
+	SeenSeconds   float64 `json:"seenSeconds"`  // Vivo e visto (spotted) por algum inimigo
+	SeenPct       float64 `json:"seenPct"`
+	Deaths        int     `json:"deaths"`        // Mortes para inimigos
+	UnawareDeaths int     `json:"unawareDeaths"` // Mortes para um inimigo que o jogador não via
+	UnawarePct    float64 `json:"unawarePct"`
+}
+
+// engagement é um contato: o inimigo ficou visível para o jogador (spotted) até sumir
+type engagement struct {
+	player      uint64
+	enemy       uint64
+	enemyRef    *common.Player
+	round       int
+	spotted     float64
+	spottedTick int
+	lastSeen    float64
+	firstShot   float64 // 0 = sem disparo
+	firstDamage float64 // 0 = sem dano
+
+	// Convergência da mira do avistamento até o primeiro disparo
+	initialError float64
+	shotError    float64
+	samples      []CrosshairSample
+	lastSample   float64
+}
+
+// engagementTracker abre um contato quando o inimigo passa a estar spotted pelo jogador e
+// mede o tempo até o primeiro disparo e o primeiro dano do jogador
+type engagementTracker struct {
+	open map[[2]uint64]*engagement
+	done []*engagement
+
+	// Exposição e mortes sem ver o killer
+	lastObserve float64
+	awareness   map[uint64]*AwarenessStats
+}
+
+func newEngagementTracker() *engagementTracker {
+	return &engagementTracker{
+		open:      make(map[[2]uint64]*engagement),
+		awareness: make(map[uint64]*AwarenessStats),
+	}
+}
+
+func (t *engagementTracker) awarenessOf(id uint64) *AwarenessStats {
+	stats, exists := t.awareness[id]
+	if !exists {
+		stats = &AwarenessStats{}
+		t.awareness[id] = stats
+	}
+	return stats
+}
+
+// isSpotting diz se player está vendo enemy agora (os dois vivos e com pawn)
+func isSpotting(player, enemy *common.Player) bool {
+	if !player.IsAlive() || !enemy.IsAlive() || enemy.PlayerPawnEntity() == nil {
+		return false
+	}
+	return enemy.IsSpottedBy(player)
+}
+
+// observe atualiza os contatos com o spotted atual; chamado a cada frame com o round rolando
+func (t *engagementTracker) observe(players []*common.Player, round int, now float64, tick int) {
+	dt := now - t.lastObserve
+	if dt > maxObserveGap {
+		dt = 0
+	}
+	t.lastObserve = now
+
+	for _, player := range players {
+		if player == nil || participantID(player) == 0 {
+			continue
+		}
+		if player.IsAlive() {
+			t.awarenessOf(participantID(player)).AliveSeconds += dt
+		}
+		seen := false
+		for _, enemy := range players {
+			if enemy == nil || participantID(enemy) == 0 || !isEnemy(player, enemy) {
+				continue
+			}
+			if isSpotting(enemy, player) {
+				seen = true
+			}
+			key := [2]uint64{participantID(player), participantID(enemy)}
+			current, exists := t.open[key]
+			switch {
+			case isSpotting(player, enemy):
+				if !exists {
+					current = &engagement{
+						player:       key[0],
+						enemy:        key[1],
+						enemyRef:     enemy,
+						round:        round,
+						spotted:      now,
+						spottedTick:  tick,
+						initialError: crosshairError(player, enemy),
+					}
+					current.samples = []CrosshairSample{{TimeMs: 0, ErrorDeg: current.initialError}}
+					current.lastSample = now
+					t.open[key] = current
+				}
+				current.lastSeen = now
+				if current.firstShot == 0 && now-current.spotted <= maxReactionTime && now-current.lastSample >= crosshairSampleInterval {
+					current.samples = append(current.samples, CrosshairSample{
+						TimeMs:   (now - current.spotted) * 1000,
+						ErrorDeg: crosshairError(player, enemy),
+					})
+					current.lastSample = now
+				}
+			case exists && (!player.IsAlive() || !enemy.IsAlive() || now-current.lastSeen > engagementGap):
+				t.close(key)
+			}
+		}
+		if seen {
+			t.awarenessOf(participantID(player)).SeenSeconds += dt
+		}
+	}
+}
+
+// isEnemy diz se os dois estão em lados opostos (T x CT)
+func isEnemy(a, b *common.Player) bool {
+	playing := func(p *common.Player) bool {
+		return p.Team == common.TeamTerrorists || p.Team == common.TeamCounterTerrorists
+	}
+	return playing(a) && playing(b) && a.Team != b.Team
+}
+
+// shot marca o primeiro disparo no contato aberto cujo inimigo está mais perto da mira
+// do jogador, com o erro de mira nesse instante. Os outros contatos abertos não contam o disparo.
+func (t *engagementTracker) shot(shooter *common.Player, weapon *common.Equipment, now float64) {
+	if !isGun(weapon) {
+		return
+	}
+	id := participantID(shooter)
+	var target *engagement
+	targetError := 0.0
+	for _, current := range t.open {
+		if current.player != id {
+			continue
+		}
+		if err := crosshairError(shooter, current.enemyRef); target == nil || err < targetError {
+			target, targetError = current, err
+		}
+	}
+	if target != nil && target.firstShot == 0 {
+		target.firstShot = now
+		target.shotError = targetError
+	}
+}
+
+// damage marca o primeiro dano do atacante no contato com a vítima
+func (t *engagementTracker) damage(attacker, victim *common.Player, now float64) {
+	current, exists := t.open[[2]uint64{participantID(attacker), participantID(victim)}]
+	if exists && current.firstDamage == 0 {
+		current.firstDamage = now
+	}
+}
+
+// death registra a morte da vítima para um inimigo. Sem contato aberto com o killer
+// (não o via há mais de engagementGap) a vítima morreu sem ver quem a matou.
+func (t *engagementTracker) death(killer, victim *common.Player) {
+	if killer == nil || victim == nil || !isEnemy(killer, victim) {
+		return
+	}
+	stats := t.awarenessOf(participantID(victim))
+	stats.Deaths++
+	if _, aware := t.open[[2]uint64{participantID(victim), participantID(killer)}]; !aware {
+		stats.UnawareDeaths++
+	}
+}
+
+// awarenessStats devolve a exposição do jogador; nil se ele não foi observado
+func (t *engagementTracker) awarenessStats(id uint64) *AwarenessStats {
+	stats, exists := t.awareness[id]
+	if !exists {
+		return nil
+	}
+	result := *stats
+	if result.AliveSeconds > 0 {
+		result.SeenPct = result.SeenSeconds / result.AliveSeconds * 100
+	}
+	if result.Deaths > 0 {
+		result.UnawarePct = float64(result.UnawareDeaths) / float64(result.Deaths) * 100
+	}
+	return &result
+}
+
+func (t *engagementTracker) close(key [2]uint64) {
+	t.done = append(t.done, t.open[key])
+	delete(t.open, key)
+}
+
+// closeAll encerra os contatos abertos (fim de round)
+func (t *engagementTracker) closeAll() {
+	for key := range t.open {
+		t.close(key)
+	}
+}
+
+// reset descarta os contatos (restart da partida)
+func (t *engagementTracker) reset() {
+	clear(t.open)
+	clear(t.awareness)
+	t.done = nil
+}
+
+// stats resume os contatos do jogador; nil se ele não avistou ninguém
+func (t *engagementTracker) stats(id uint64) *ReactionStats {
+	reaction := &ReactionStats{}
+	reactions := []float64{}
+	toDamage := []float64{}
+	for _, current := range t.done {
+		if current.player != id {
+			continue
+		}
+		reaction.Engagements++
+		if delay := current.firstShot - current.spotted; current.firstShot > 0 && delay <= maxReactionTime {
+			reactions = append(reactions, delay*1000)
+		}
+		if delay := current.firstDamage - current.spotted; current.firstDamage > 0 && delay <= maxReactionTime {
+			toDamage = append(toDamage, delay*1000)
+		}
+	}
+	if reaction.Engagements == 0 {
+		return nil
+	}
+	reaction.Reactions = len(reactions)
+	reaction.MedianReactionMs = median(reactions)
+	reaction.DamageEngagements = len(toDamage)
+	reaction.MedianTimeToDamageMs = median(toDamage)
+	return reaction
+}
+
+// median devolve a mediana (0 sem valores)
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+// crosshair resume o erro de mira ao avistar inimigos; nil se o jogador não avistou ninguém
+func (t *engagementTracker) crosshair(id uint64) *CrosshairStats {
+	initial := []float64{}
+	atShot := []float64{}
+	for _, current := range t.done {
+		if current.player != id {
+			continue
+		}
+		initial = append(initial, current.initialError)
+		if current.firstShot > 0 && current.firstShot-current.spotted <= maxReactionTime {
+			atShot = append(atShot, current.shotError)
+		}
+	}
+	if len(initial) == 0 {
+		return nil
+	}
+	stats := &CrosshairStats{
+		Engagements:        len(initial),
+		MedianErrorDeg:     median(initial),
+		MedianShotErrorDeg: median(atShot),
+	}
+	stats.Score = crosshairScore(stats.MedianErrorDeg)
+	return stats
+}
+
+// crosshairEngagements lista a mira em cada contato, em ordem de avistamento
+func (t *engagementTracker) crosshairEngagements() []CrosshairEngagement {
+	list := make([]CrosshairEngagement, 0, len(t.done))
+	for _, current := range t.done {
+		entry := CrosshairEngagement{
+			Round:        current.round,
+			Tick:         current.spottedTick,
+			SteamID:      current.player,
+			EnemySteamID: current.enemy,
+			ErrorDeg:     current.initialError,
+			Samples:      current.samples,
+		}
+		if current.firstShot > 0 && current.firstShot-current.spotted <= maxReactionTime {
+			shotError := current.shotError
+			timeToShot := (current.firstShot - current.spotted) * 1000
+			entry.ShotErrorDeg = &shotError
+			entry.TimeToShotMs = &timeToShot
+		}
+		list = append(list, entry)
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].Tick < list[j].Tick })
+	return list
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"testing"
+
+	common "github.com/markus-wa/demoinfocs-golang/v5/pkg/demoinfocs/common"
+)
+
+// Um T na origem olhando para +X e dois CTs: e1 à frente (mira em cima) e e2 a 90° à
+// esquerda. Instantes em frações de 2 para os tempos em ms serem exatos.
+func TestEngagementTrackerKnownAnswers(t *testing.T) {
+	player, playerPawn := pawnPlayer(1, common.TeamTerrorists, ak47)
+	e1, e1Pawn := pawnPlayer(2, common.TeamCounterTerrorists, ak47)
+	e2, e2Pawn := pawnPlayer(3, common.TeamCounterTerrorists, ak47)
+	e1Pawn.pos = r3Vec(1000, 0, 0)
+	e2Pawn.pos = r3Vec(0, 1000, 0)
+	players := []*common.Player{player, e1, e2}
+	tracker := newEngagementTracker()
+
+	// 10.0: avista os dois; e1 também vê o jogador
+	e1Pawn.spottedBy(player)
+	e2Pawn.spottedBy(player)
+	playerPawn.spottedBy(e1)
+	tracker.observe(players, 1, 10.0, 640)
+	if len(tracker.open) != 3 {
+		t.Fatalf("%d contatos abertos, want 3", len(tracker.open))
+	}
+
+	// 10.25: disparo com a mira em e1 marca só o contato com e1
+	tracker.observe(players, 1, 10.25, 656)
+	tracker.shot(player, ak47, 10.25)
+	// 10.375: primeiro dano em e1; disparo de granada não é reação
+	tracker.damage(player, e1, 10.375)
+	tracker.shot(player, he, 10.375)
+	// 10.5: vira para e2 e atira
+	playerPawn.look(90, 0)
+	tracker.observe(players, 1, 10.5, 672)
+	tracker.shot(player, ak47, 10.5)
+	// 10.625: e1 morre vendo o jogador; e2 some da visão
+	tracker.death(player, e1)
+	e1Pawn.die()
+	e2Pawn.spottedBy()
+	tracker.observe(players, 1, 10.625, 680)
+	// 11.25: e2 sumiu há mais de engagementGap
+	tracker.observe(players, 1, 11.25, 720)
+	if len(tracker.open) != 0 {
+		t.Fatalf("%d contatos ainda abertos", len(tracker.open))
+	}
+
+	// 11.5: disparo entre dois contatos não conta para nenhum
+	tracker.shot(player, ak47, 11.5)
+	// 12.0: e2 volta a ser visto e morre sem ter visto o jogador
+	e2Pawn.spottedBy(player)
+	tracker.observe(players, 1, 12.0, 768)
+	tracker.death(player, e2)
+	tracker.closeAll()
+
+	stamped := map[[2]float64]float64{}
+	for _, current := range tracker.done {
+		if current.player == 1 {
+			stamped[[2]float64{float64(current.enemy), current.spotted}] = current.firstShot
+		}
+	}
+	want := map[[2]float64]float64{{2, 10.0}: 10.25, {3, 10.0}: 10.5, {3, 12.0}: 0}
+	for key, shot := range want {
+		if got, exists := stamped[key]; !exists || got != shot {
+			t.Errorf("contato com %v avistado em %v: firstShot %v (existe %v), want %v", key[0], key[1], got, exists, shot)
+		}
+	}
+
+	reaction := tracker.stats(1)
+	wantReaction := ReactionStats{Engagements: 3, Reactions: 2, MedianReactionMs: 375, DamageEngagements: 1, MedianTimeToDamageMs: 375}
+	if *reaction != wantReaction {
+		t.Errorf("reação = %+v, want %+v", *reaction, wantReaction)
+	}
+	if tracker.stats(4) != nil {
+		t.Error("quem não avistou ninguém deveria ter stats nil")
+	}
+
+	// Vivo 0.625s com o round rolando (intervalos acima de maxObserveGap não contam),
+	// visto por e1 em 0.5s deles
+	awareness := tracker.awarenessStats(1)
+	if awareness.AliveSeconds != 0.625 || awareness.SeenSeconds != 0.5 || awareness.SeenPct != 80 {
+		t.Errorf("exposição = %+v, want 0.625s vivo, 0.5s visto (80%%)", *awareness)
+	}
+	if aware := tracker.awarenessStats(2); aware.Deaths != 1 || aware.UnawareDeaths != 0 {
+		t.Errorf("e1 morreu vendo o killer: %+v", *aware)
+	}
+	if unaware := tracker.awarenessStats(3); unaware.Deaths != 1 || unaware.UnawareDeaths != 1 || unaware.UnawarePct != 100 {
+		t.Errorf("e2 morreu sem ver o killer: %+v", *unaware)
+	}
+}
+
+// Reações acima de maxReactionTime contam o contato, mas não a mediana
+func TestEngagementTrackerSlowReaction(t *testing.T) {
+	player, _ := pawnPlayer(1, common.TeamTerrorists, ak47)
+	enemy, enemyPawn := pawnPlayer(2, common.TeamCounterTerrorists, ak47)
+	enemyPawn.pos = r3Vec(1000, 0, 0)
+	enemyPawn.spottedBy(player)
+	players := []*common.Player{player, enemy}
+	tracker := newEngagementTracker()
+
+	for i := range 6 {
+		tracker.observe(players, 1, 10+float64(i)*0.5, 640+i*32)
+	}
+	tracker.shot(player, ak47, 12.5)
+	tracker.damage(player, enemy, 12.5)
+	tracker.closeAll()
+
+	want := ReactionStats{Engagements: 1}
+	if got := tracker.stats(1); *got != want {
+		t.Errorf("reação = %+v, want %+v", *got, want)
+	}
+}
+
+func TestMedian(t *testing.T) {
+	cases := []struct {
+		values []float64
+		want   float64
+	}{
+		{nil, 0},
+		{[]float64{300}, 300},
+		{[]float64{500, 100, 300}, 300},
+		{[]float64{400, 100, 300, 200}, 250},
+	}
+	for _, c := range cases {
+		if got := median(c.values); got != c.want {
+			t.Errorf("median(%v) = %v, want %v", c.values, got, c.want)
+		}
+	}
+}
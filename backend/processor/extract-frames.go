@@ -1,3 +1,7 @@
+//go:build ignore
+
+// Compilado à parte junto com participants.go (mesmos IDs de participante da análise):
+// go build -o extract-frames extract-frames.go participants.go
 package main
 
 import (
@@ -22,14 +26,17 @@ type Frame struct {
 }
 
 type PlayerFrame struct {
-	SteamID  uint64    `json:"steamID"`
-	Name     string    `json:"name"`
-	Team     string    `json:"team"`
-	Position Position  `json:"position"`
-	IsAlive  bool      `json:"isAlive"`
-	Health   int       `json:"health"`
-	Armor    int       `json:"armor"`
-	Weapon   string    `json:"weapon,omitempty"`
+	SteamID  uint64   `json:"steamID"`
+	Name     string   `json:"name"`
+	Team     string   `json:"team"`
+	Position Position `json:"position"`
+	IsAlive  bool     `json:"isAlive"`
+	Health   int      `json:"health"`
+	Armor    int      `json:"armor"`
+	Weapon   string   `json:"weapon,omitempty"`
+	// Visibilidade pelo spotted do jogo (m_bSpottedByMask, não é linha de visão exata)
+	IsSpotted bool     `json:"isSpotted"`      // Algum inimigo vivo está vendo o jogador
+	Sees      []uint64 `json:"sees,omitempty"` // IDs (participantID) dos inimigos que o jogador está vendo
 }
 
 type Position struct {
@@ -277,7 +284,7 @@ func main() {
 
 			pos := player.Position()
 			playerFrame := PlayerFrame{
-				SteamID: player.SteamID64,
+				SteamID: participantID(player),
 				Name:    player.Name,
 				Team:    teamToString(player.Team),
 				Position: Position{
@@ -295,6 +302,19 @@ func main() {
 				playerFrame.Weapon = weapon.Type.String()
 			}
 
+			// Quem vê quem (só entre inimigos vivos)
+			for _, enemy := range players {
+				if enemy == nil || enemy.Team == player.Team || !player.IsAlive() || !enemy.IsAlive() {
+					continue
+				}
+				if enemy.PlayerPawnEntity() != nil && enemy.IsSpottedBy(player) {
+					playerFrame.Sees = append(playerFrame.Sees, participantID(enemy))
+				}
+				if player.PlayerPawnEntity() != nil && player.IsSpottedBy(enemy) {
+					playerFrame.IsSpotted = true
+				}
+			}
+
 			playerFrames = append(playerFrames, playerFrame)
 		}
 
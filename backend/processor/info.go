@@ -0,0 +1,226 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/golang/snappy"
+	demoinfocs "github.com/markus-wa/demoinfocs-golang/v5/pkg/demoinfocs"
+	events "github.com/markus-wa/demoinfocs-golang/v5/pkg/demoinfocs/events"
+	"github.com/markus-wa/demoinfocs-golang/v5/pkg/demoinfocs/msg"
+	"google.golang.org/protobuf/proto"
+)
+
+// DemoInfo contém apenas os metadados do cabeçalho da demo (sem ParseToEnd)
+type DemoInfo struct {
+	Map             string       `json:"map"`
+	ServerName      string       `json:"serverName"`
+	ClientName      string       `json:"clientName"`
+	NetworkProtocol int          `json:"networkProtocol"`
+	PlaybackTicks   int          `json:"playbackTicks"`
+	PlaybackTime    float64      `json:"playbackTime"`
+	Duration        string       `json:"duration"`
+	TickRate        float64      `json:"tickRate"`
+	Players         []InfoPlayer `json:"players"`
+}
+
+type InfoPlayer struct {
+	SteamID uint64 `json:"steamID"`
+	Name    string `json:"name"`
+	IsBot   bool   `json:"isBot,omitempty"`
+}
+
+// Tamanho do preâmbulo de demos CS2: "PBDEMS2\0" + offset do FileInfo + offset dos SpawnGroups
+const demoPreambleSize = 16
+
+func runInfo(args []string) {
+	fs := flag.NewFlagSet("info", flag.ExitOnError)
+	logOpts := addLogFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Uso: %s info <demo_path>\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	logOpts.setup()
+
+	if fs.NArg() < 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	info, err := readDemoInfo(fs.Arg(0))
+	if err != nil {
+		slog.Error("Erro ao ler cabeçalho da demo", "demo", fs.Arg(0), "err", err)
+		os.Exit(1)
+	}
+
+	jsonData, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		slog.Error("Erro ao serializar JSON", "err", err)
+		os.Exit(1)
+	}
+
+	fmt.Print(string(jsonData))
+}
+
+// readDemoInfo lê o cabeçalho e a lista de jogadores parseando só os frames de signon.
+// A duração vem do CDemoFileInfo, lido diretamente pelo offset do preâmbulo.
+func readDemoInfo(demoPath string) (*DemoInfo, error) {
+	f, err := openDemo(demoPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info := &DemoInfo{Players: []InfoPlayer{}}
+
+	// Demos comprimidas ou vindas do stdin não permitem Seek: duração fica de fora
+	if rs, ok := f.(io.ReadSeeker); ok {
+		fileInfo, err := readFileInfo(rs)
+		if err != nil {
+			return nil, err
+		}
+		if fileInfo != nil {
+			info.PlaybackTicks = int(fileInfo.GetPlaybackTicks())
+			info.PlaybackTime = float64(fileInfo.GetPlaybackTime())
+			info.Duration = formatDuration(time.Duration(info.PlaybackTime * float64(time.Second)))
+		}
+
+		if _, err := rs.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+	}
+
+	p, err := newDemoParser(f)
+	if err != nil {
+		return nil, err
+	}
+	defer p.Close()
+
+	signonDone := false
+	seenPlayers := make(map[uint64]bool)
+
+	p.RegisterNetMessageHandler(func(m *msg.CDemoFileHeader) {
+		info.Map = m.GetMapName()
+		info.ServerName = m.GetServerName()
+		info.ClientName = m.GetClientName()
+		info.NetworkProtocol = int(m.GetNetworkProtocol())
+	})
+
+	// SyncTick marca o fim do signon: a partir daqui a userinfo já foi recebida
+	p.RegisterNetMessageHandler(func(m *msg.CDemoSyncTick) {
+		signonDone = true
+	})
+
+	p.RegisterEventHandler(func(e events.PlayerInfo) {
+		if e.Info.IsHltv || e.Info.XUID == 0 || seenPlayers[e.Info.XUID] {
+			return
+		}
+		seenPlayers[e.Info.XUID] = true
+		info.Players = append(info.Players, InfoPlayer{
+			SteamID: e.Info.XUID,
+			Name:    e.Info.Name,
+			IsBot:   e.Info.IsFakePlayer,
+		})
+	})
+
+	if err := parseUntil(p, func() bool { return signonDone }); err != nil {
+		return nil, err
+	}
+
+	if tickRate := p.TickRate(); tickRate > 0 {
+		info.TickRate = tickRate
+	} else if info.PlaybackTime > 0 {
+		info.TickRate = float64(info.PlaybackTicks) / info.PlaybackTime
+	}
+
+	if info.Map == "" {
+		info.Map = mapFromPath(demoPath)
+	}
+
+	return info, nil
+}
+
+// parseUntil parseia frames até done ou o fim da demo, convertendo panics do parser
+// (demos corrompidas) em erro como parseToEnd
+func parseUntil(p demoinfocs.Parser, done func() bool) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w: %v", errCorruptDemo, r)
+		}
+	}()
+
+	for !done() {
+		moreFrames, err := p.ParseNextFrame()
+		if err != nil {
+			return err
+		}
+		if !moreFrames {
+			return nil
+		}
+	}
+	return nil
+}
+
+// readFileInfo lê o CDemoFileInfo que fica no fim da demo, usando o offset do preâmbulo.
+// Retorna nil sem erro se o offset não estiver preenchido (demo ainda sendo gravada).
+func readFileInfo(r io.ReadSeeker) (*msg.CDemoFileInfo, error) {
+	preamble := make([]byte, demoPreambleSize)
+	if _, err := io.ReadFull(r, preamble); err != nil {
+		return nil, fmt.Errorf("%w: %v", demoinfocs.ErrInvalidFileType, err)
+	}
+	if string(preamble[:8]) != "PBDEMS2\x00" {
+		return nil, demoinfocs.ErrInvalidFileType
+	}
+
+	offset := binary.LittleEndian.Uint32(preamble[8:12])
+	if offset == 0 {
+		return nil, nil
+	}
+	if _, err := r.Seek(int64(offset), io.SeekStart); err != nil {
+		return nil, nil
+	}
+
+	br := bufio.NewReader(r)
+	cmd, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, nil
+	}
+	if _, err := binary.ReadUvarint(br); err != nil { // tick
+		return nil, nil
+	}
+	size, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, nil
+	}
+
+	msgType := msg.EDemoCommands(cmd) &^ msg.EDemoCommands_DEM_IsCompressed
+	if msgType != msg.EDemoCommands_DEM_FileInfo {
+		return nil, nil
+	}
+
+	buf := make([]byte, size)
+	if _, err := io.ReadFull(br, buf); err != nil {
+		return nil, nil
+	}
+	if msg.EDemoCommands(cmd)&msg.EDemoCommands_DEM_IsCompressed != 0 {
+		buf, err = snappy.Decode(nil, buf)
+		if err != nil {
+			return nil, errors.New("CDemoFileInfo comprimido está corrompido")
+		}
+	}
+
+	fileInfo := &msg.CDemoFileInfo{}
+	if err := proto.Unmarshal(buf, fileInfo); err != nil {
+		return nil, fmt.Errorf("CDemoFileInfo inválido: %w", err)
+	}
+	return fileInfo, nil
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Magic bytes dos formatos de compressão aceitos (.dem.gz, .dem.bz2, .dem.zst)
+var (
+	gzipMagic  = []byte{0x1f, 0x8b}
+	bzip2Magic = []byte("BZh")
+	zstdMagic  = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// demoReader junta o stream (possivelmente descomprimido) com o fechamento da origem
+type demoReader struct {
+	io.Reader
+	closeFn func() error
+}
+
+func (r *demoReader) Close() error {
+	return r.closeFn()
+}
+
+// heldErrReader nunca devolve dados junto com erro: o erro fica para a leitura seguinte.
+// Os descompressores devolvem os últimos bytes junto com io.EOF, e o bit reader do parser
+// entra em panic com qualquer erro na primeira leitura (demos menores que o buffer dele).
+type heldErrReader struct {
+	r   io.Reader
+	err error
+}
+
+func (h *heldErrReader) Read(p []byte) (int, error) {
+	if h.err != nil {
+		return 0, h.err
+	}
+	n, err := h.r.Read(p)
+	if n > 0 && err != nil {
+		h.err = err
+		err = nil
+	}
+	return n, err
+}
+
+// openDemo abre a demo detectando a compressão pelos magic bytes e descomprimindo em stream.
+// Com demoPath "-" a demo é lida da entrada padrão.
+// Demos sem compressão em arquivo regular são devolvidas como *os.File (permitem Seek).
+func openDemo(demoPath string) (io.ReadCloser, error) {
+	f := os.Stdin
+	if demoPath != "-" {
+		var err error
+		f, err = os.Open(demoPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	br := bufio.NewReaderSize(f, 64*1024)
+	magic, err := br.Peek(len(zstdMagic))
+	if err != nil && err != io.EOF {
+		f.Close()
+		return nil, err
+	}
+	if len(magic) == 0 {
+		f.Close()
+		return nil, errors.New("demo vazia")
+	}
+
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		return &demoReader{Reader: &heldErrReader{r: gz}, closeFn: func() error {
+			gz.Close()
+			return f.Close()
+		}}, nil
+
+	case bytes.HasPrefix(magic, bzip2Magic):
+		return &demoReader{Reader: &heldErrReader{r: bzip2.NewReader(br)}, closeFn: f.Close}, nil
+
+	case bytes.HasPrefix(magic, zstdMagic):
+		zr, err := zstd.NewReader(br)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		return &demoReader{Reader: &heldErrReader{r: zr}, closeFn: func() error {
+			zr.Close()
+			return f.Close()
+		}}, nil
+	}
+
+	// Sem compressão: volta ao início do arquivo quando possível (stdin não permite Seek)
+	if _, err := f.Seek(0, io.SeekStart); err == nil {
+		return f, nil
+	}
+	return &demoReader{Reader: &heldErrReader{r: br}, closeFn: f.Close}, nil
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/hex"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// minimalDemoHex comprimida com bzip2 -9 (a biblioteca padrão só descomprime bzip2)
+const minimalDemoBzip2Hex = "425a6839314159265359d3ddbc6e0000187fc9789240505030100096024d008e0b9b0054004040200020005461932680d00f5346864d18d4229e91883d468d007a401a030529402c414a6420913127f64d692db6133537af87021c1f1819038145cc9787537a6778eadf147c199a0d86c97889043f177245385090d3ddbc6e"
+
+func gzipBytes(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func zstdBytes(t *testing.T, data []byte) []byte {
+	t.Helper()
+	enc, err := zstd.NewWriter(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer enc.Close()
+	return enc.EncodeAll(data, nil)
+}
+
+// Demos comprimidas menores que o buffer do parser: o descompressor devolve os dados
+// junto com io.EOF na primeira leitura, o que derrubava o processo com panic
+func TestCompressedDemoRoundTrip(t *testing.T) {
+	bz2, err := hex.DecodeString(minimalDemoBzip2Hex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fixtures := map[string][]byte{
+		"minimal.dem.gz":  gzipBytes(t, minimalDemo(t)),
+		"minimal.dem.bz2": bz2,
+		"minimal.dem.zst": zstdBytes(t, minimalDemo(t)),
+	}
+	for name, data := range fixtures {
+		t.Run(name, func(t *testing.T) {
+			path := writeFixture(t, name, data)
+
+			info, err := readDemoInfo(path)
+			if err != nil {
+				t.Fatalf("info: %v", err)
+			}
+			if info.Map != "de_nuke" {
+				t.Errorf("map = %q, want de_nuke", info.Map)
+			}
+
+			analysis, err := analyzeDemo(context.Background(), path, analyzeOptions{})
+			if err != nil {
+				t.Fatalf("analyze: %v", err)
+			}
+			if analysis.Metadata.Truncated {
+				t.Errorf("demo completa marcada como truncada: %+v", analysis.Metadata)
+			}
+		})
+	}
+}
+
+// Stream descomprimido vazio ou truncado vira erro, não panic
+func TestCompressedDemoEmptyOrTruncated(t *testing.T) {
+	fixtures := map[string][]byte{
+		"empty.dem.gz":     gzipBytes(t, nil),
+		"truncated.dem.gz": gzipBytes(t, minimalDemo(t))[:40],
+	}
+	for name, data := range fixtures {
+		t.Run(name, func(t *testing.T) {
+			if _, err := readDemoInfo(writeFixture(t, name, data)); err == nil {
+				t.Error("info deveria dar erro")
+			}
+		})
+	}
+
+	if _, err := analyzeDemo(context.Background(), writeFixture(t, "empty.dem.gz", gzipBytes(t, nil)), analyzeOptions{}); err == nil {
+		t.Error("analyze de stream vazio deveria dar erro")
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	common "github.com/markus-wa/demoinfocs-golang/v5/pkg/demoinfocs/common"
+)
+
+// knifeLoadout olha os jogadores vivos no fim do freezetime. knifeOnly: todos seguram uma
+// faca e nada além dela (C4 permitida); jogador vivo sem nenhuma arma não conta como faca
+// (equipamento ainda não chegou). broke: ninguém tem dinheiro, então ninguém compra arma
+// no resto do round. A faca é identificada pelo tipo do equipamento, que cobre todas as
+// variantes (karambit, bayonet...).
+func knifeLoadout(players []*common.Player) (knifeOnly, broke bool) {
+	alive := 0
+	broke = true
+	for _, player := range players {
+		if player == nil || !player.IsAlive() {
+			continue
+		}
+		if player.Team != common.TeamTerrorists && player.Team != common.TeamCounterTerrorists {
+			continue
+		}
+		alive++
+		if player.Money() > 0 {
+			broke = false
+		}
+		if !holdsOnlyKnife(player.Weapons()) {
+			return false, false
+		}
+	}
+	return alive > 0, broke
+}
+
+// holdsOnlyKnife diz se o inventário tem faca e, fora ela, no máximo a C4
+func holdsOnlyKnife(weapons []*common.Equipment) bool {
+	hasKnife := false
+	for _, weapon := range weapons {
+		if weapon == nil {
+			continue
+		}
+		switch weapon.Type {
+		case common.EqKnife:
+			hasKnife = true
+		case common.EqBomb:
+		default:
+			return false
+		}
+	}
+	return hasKnife
+}
+
+// isKnifeRoundKill diz se a kill é compatível com round de faca: faca, ou mortes sem arma
+// de jogador (queda, C4, suicídio)
+func isKnifeRoundKill(weapon *common.Equipment) bool {
+	if weapon == nil {
+		return true
+	}
+	switch weapon.Type {
+	case common.EqKnife, common.EqWorld, common.EqBomb, common.EqUnknown:
+		return true
+	}
+	return false
+}
+
+// pendingKnifeRound segura o que acontece num round com loadout só de faca mas com dinheiro:
+// alguém pode comprar arma depois do freezetime. Kills, dano e disparos ficam guardados em
+// ordem; uma kill com outra arma torna o round oficial e aplica tudo (commit), e o round
+// terminado só com faca descarta o que foi guardado (discard).
+type pendingKnifeRound struct {
+	round   int // 0 = nenhum round pendente
+	effects []func()
+}
+
+func (p *pendingKnifeRound) start(round int) {
+	p.round = round
+	p.effects = nil
+}
+
+func (p *pendingKnifeRound) active(round int) bool {
+	return p.round != 0 && p.round == round
+}
+
+func (p *pendingKnifeRound) add(effect func()) {
+	p.effects = append(p.effects, effect)
+}
+
+// commit aplica os efeitos guardados na ordem em que aconteceram
+func (p *pendingKnifeRound) commit() {
+	effects := p.effects
+	p.round, p.effects = 0, nil
+	for _, effect := range effects {
+		effect()
+	}
+}
+
+// discard descarta os efeitos guardados e devolve quantos eram
+func (p *pendingKnifeRound) discard() int {
+	discarded := len(p.effects)
+	p.round, p.effects = 0, nil
+	return discarded
+}
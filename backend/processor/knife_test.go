@@ -0,0 +1,110 @@
+package main
+
+import (
+	"slices"
+	"testing"
+
+	common "github.com/markus-wa/demoinfocs-golang/v5/pkg/demoinfocs/common"
+)
+
+// Round de faca só com kills de faca ou sem arma de jogador; qualquer arma comprada desfaz
+func TestIsKnifeRoundKill(t *testing.T) {
+	tests := []struct {
+		weapon *common.Equipment
+		want   bool
+	}{
+		{common.NewEquipment(common.EqKnife), true},
+		{common.NewEquipment(common.EqWorld), true},
+		{common.NewEquipment(common.EqBomb), true},
+		{nil, true},
+		{common.NewEquipment(common.EqGlock), false},
+		{common.NewEquipment(common.EqHE), false},
+		{common.NewEquipment(common.EqZeus), false},
+	}
+	for _, tt := range tests {
+		if got := isKnifeRoundKill(tt.weapon); got != tt.want {
+			t.Errorf("isKnifeRoundKill(%s) = %v, want %v", weaponName(tt.weapon), got, tt.want)
+		}
+	}
+}
+
+// Inventário vazio (equipamento ainda não chegou) não é loadout de faca
+func TestHoldsOnlyKnife(t *testing.T) {
+	knife := common.NewEquipment(common.EqKnife)
+	bomb := common.NewEquipment(common.EqBomb)
+	glock := common.NewEquipment(common.EqGlock)
+
+	tests := []struct {
+		name    string
+		weapons []*common.Equipment
+		want    bool
+	}{
+		{"faca", []*common.Equipment{knife}, true},
+		{"faca e C4", []*common.Equipment{knife, bomb}, true},
+		{"vazio", nil, false},
+		{"só C4", []*common.Equipment{bomb}, false},
+		{"faca e pistola", []*common.Equipment{knife, glock}, false},
+	}
+	for _, tt := range tests {
+		if got := holdsOnlyKnife(tt.weapons); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// Round pendente: efeitos guardados são aplicados em ordem no commit e somem no discard
+func TestPendingKnifeRound(t *testing.T) {
+	var applied []int
+	pending := &pendingKnifeRound{}
+
+	pending.start(3)
+	if !pending.active(3) || pending.active(4) {
+		t.Fatal("round 3 deveria estar pendente, e só ele")
+	}
+	for i := 1; i <= 3; i++ {
+		pending.add(func() { applied = append(applied, i) })
+	}
+	if len(applied) != 0 {
+		t.Fatalf("efeitos aplicados antes do commit: %v", applied)
+	}
+	pending.commit()
+	if !slices.Equal(applied, []int{1, 2, 3}) || pending.active(3) {
+		t.Fatalf("commit aplicou %v (ativo=%v), want [1 2 3] e inativo", applied, pending.active(3))
+	}
+
+	applied = nil
+	pending.start(5)
+	pending.add(func() { applied = append(applied, 5) })
+	if n := pending.discard(); n != 1 || len(applied) != 0 || pending.active(5) {
+		t.Fatalf("discard: n=%d applied=%v", n, applied)
+	}
+}
+
+// Disparos, dano e kill guardados num round de faca pendente chegam aos stats quando
+// a primeira kill com arma torna o round oficial
+func TestPendingKnifeRoundCommitStats(t *testing.T) {
+	attacker := testPlayer(1, common.TeamTerrorists)
+	victim := testPlayer(2, common.TeamCounterTerrorists)
+	acc := newStatsAccumulator()
+	pending := &pendingKnifeRound{}
+	pending.start(1)
+
+	knife := common.NewEquipment(common.EqKnife)
+	deagle := common.NewEquipment(common.EqDeagle)
+	// Kill de faca antes de alguém comprar arma
+	pending.add(func() { acc.damage(attacker, victim, knife, 65, 10) })
+	pending.add(func() { acc.kill(attacker, victim, nil, weaponName(knife), false, false) })
+	// Depois da compra: disparo e dano de Deagle guardados até a kill com arma
+	pending.add(func() { acc.shot(attacker, deagle) })
+	pending.add(func() { acc.damage(attacker, victim, deagle, 100, 20) })
+	pending.commit()
+	acc.kill(attacker, victim, nil, weaponName(deagle), true, false)
+
+	got := acc.get(1)
+	if got.Kills != 2 || got.HSKills != 1 || got.Damage != 165 {
+		t.Errorf("stats depois do commit: %+v", *got)
+	}
+	if w := acc.weapons[1][weaponName(deagle)]; w == nil || w.Shots != 1 || w.Hits != 1 {
+		t.Errorf("Deagle: %+v", w)
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+)
+
+// parserLog recebe os avisos do demoinfocs (events.ParserWarn), separado dos logs de
+// diagnóstico para poder ir para outro arquivo (-parser-log) ou nível (-parser-log-level)
+var parserLog = slog.Default().With("channel", "parser")
+
+// logOptions são as flags de log comuns a todos os comandos
+type logOptions struct {
+	level          string
+	format         string
+	parserLogPath  string
+	parserLogLevel string
+}
+
+func addLogFlags(fs *flag.FlagSet) *logOptions {
+	opts := &logOptions{}
+	fs.StringVar(&opts.level, "log-level", "info", "nível de log: debug, info, warn ou error")
+	fs.StringVar(&opts.format, "log-format", "text", "formato do log no stderr: text ou json")
+	fs.StringVar(&opts.parserLogPath, "parser-log", "", "arquivo para os avisos do parser (padrão: junto com o log no stderr)")
+	fs.StringVar(&opts.parserLogLevel, "parser-log-level", "warn", "nível dos avisos do parser: warn mostra, error esconde")
+	return opts
+}
+
+// setup configura o logger padrão e o parserLog; em caso de flag inválida sai com código 1
+func (o *logOptions) setup() {
+	if err := o.configure(); err != nil {
+		fmt.Fprintf(os.Stderr, "Erro na configuração de log: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func (o *logOptions) configure() error {
+	var level, parserLevel slog.Level
+	if err := level.UnmarshalText([]byte(o.level)); err != nil {
+		return fmt.Errorf("-log-level inválido %q", o.level)
+	}
+	if err := parserLevel.UnmarshalText([]byte(o.parserLogLevel)); err != nil {
+		return fmt.Errorf("-parser-log-level inválido %q", o.parserLogLevel)
+	}
+	if o.format != "text" && o.format != "json" {
+		return fmt.Errorf("-log-format inválido %q (use text ou json)", o.format)
+	}
+
+	slog.SetDefault(slog.New(newLogHandler(os.Stderr, o.format, level)))
+
+	parserOut := io.Writer(os.Stderr)
+	if o.parserLogPath != "" {
+		// Fica aberto até o fim do processo
+		f, err := os.OpenFile(o.parserLogPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return fmt.Errorf("erro ao abrir -parser-log: %w", err)
+		}
+		parserOut = f
+	}
+	parserLog = slog.New(newLogHandler(parserOut, o.format, parserLevel)).With("channel", "parser")
+	return nil
+}
+
+func newLogHandler(w io.Writer, format string, level slog.Level) slog.Handler {
+	handlerOpts := &slog.HandlerOptions{Level: level}
+	if format == "json" {
+		return slog.NewJSONHandler(w, handlerOpts)
+	}
+	return slog.NewTextHandler(w, handlerOpts)
+}
@@ -1,36 +1,68 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
+	"os/signal"
+	"sort"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	demoinfocs "github.com/markus-wa/demoinfocs-golang/v5/pkg/demoinfocs"
 	common "github.com/markus-wa/demoinfocs-golang/v5/pkg/demoinfocs/common"
 	events "github.com/markus-wa/demoinfocs-golang/v5/pkg/demoinfocs/events"
+	"github.com/markus-wa/demoinfocs-golang/v5/pkg/demoinfocs/msg"
 )
 
 // Análise completa com todos os dados
 type SimpleAnalysis struct {
-	Metadata     MatchMetadata   `json:"metadata"`
-	Events       []DetailedEvent `json:"events"`
-	Players      []SimplePlayer  `json:"players"`
-	Summary      SimpleSummary   `json:"summary"`
-	Heatmap      HeatmapData     `json:"heatmap"`
-	TargetPlayer *PlayerAnalysis `json:"targetPlayer,omitempty"`
+	Metadata MatchMetadata   `json:"metadata"`
+	Events   []DetailedEvent `json:"events"`
+	Players  []SimplePlayer  `json:"players"`
+	Summary  SimpleSummary   `json:"summary"`
+	Heatmap  HeatmapData     `json:"heatmap"`
+	Rounds   []RoundInfo     `json:"rounds"` // Rounds oficiais com metade/prorrogação
+	Teams    []MatchTeam     `json:"teams"`  // Times A/B com placar por time
+	// Mira em cada contato (avistamento até o primeiro disparo)
+	Crosshair    []CrosshairEngagement `json:"crosshair"`
+	Duels        []Duel                `json:"duels"` // Trocas de dano entre dois inimigos
+	TargetPlayer *PlayerAnalysis       `json:"targetPlayer,omitempty"`
 }
 
 type MatchMetadata struct {
-	Map          string `json:"map"`
-	Duration     string `json:"duration"`
-	Rounds       int    `json:"rounds"`
-	ScoreT       int    `json:"scoreT"`
-	ScoreCT      int    `json:"scoreCT"`
-	WarmupRounds int    `json:"warmupRounds"`
-	KnifeRound   bool   `json:"knifeRound"`
-	Source       string `json:"source"` // "GC" ou "Valve"
+	Map                string             `json:"map"`
+	Duration           string             `json:"duration"`
+	Rounds             int                `json:"rounds"`
+	ScoreT             int                `json:"scoreT"`
+	ScoreCT            int                `json:"scoreCT"`
+	WarmupRounds       int                `json:"warmupRounds"`
+	KnifeRound         bool               `json:"knifeRound"`
+	Knife              *KnifeRoundResult  `json:"knife,omitempty"`          // Vencedor da faca e lado escolhido
+	Regulation         PeriodScore        `json:"regulation"`               // Placar do tempo regulamentar
+	Overtimes          int                `json:"overtimes"`                // Prorrogações jogadas
+	OvertimeScores     []PeriodScore      `json:"overtimeScores,omitempty"` // Placar de cada prorrogação
+	FirstOfficialRound int                `json:"firstOfficialRound"`       // Primeiro round depois do aquecimento e dos restarts
+	Source             string             `json:"source"`                   // "GC" ou "Valve"
+	Truncated          bool               `json:"truncated,omitempty"`      // Parse interrompido: resultado parcial
+	Error              string             `json:"error,omitempty"`          // Descrição do erro que interrompeu o parse
+	Diagnostics        *ParserDiagnostics `json:"diagnostics,omitempty"`    // Avisos do parser por tipo
+}
+
+// KnifeRoundResult registra quem venceu o round de faca e o lado que escolheu
+type KnifeRoundResult struct {
+	Round      int    `json:"round"`
+	Winner     string `json:"winner"`               // Lado do vencedor durante a faca ("T" ou "CT")
+	WinnerTeam string `json:"winnerTeam,omitempty"` // Clan name do time vencedor, quando o servidor informa
+	ChosenSide string `json:"chosenSide,omitempty"` // Lado em que o vencedor começou a partida
+	Switched   bool   `json:"switched"`             // Vencedor trocou de lado depois da faca
 }
 
 type DetailedEvent struct {
@@ -40,6 +72,8 @@ type DetailedEvent struct {
 	Round    int                    `json:"round"`
 	IsWarmup bool                   `json:"isWarmup,omitempty"`
 	IsKnife  bool                   `json:"isKnife,omitempty"`
+	Half     int                    `json:"half,omitempty"`     // Metade do período (1 ou 2)
+	Overtime int                    `json:"overtime,omitempty"` // Prorrogação (0 = tempo regulamentar)
 	Data     map[string]interface{} `json:"data,omitempty"`
 }
 
@@ -50,21 +84,53 @@ type Position struct {
 }
 
 type SimplePlayer struct {
-	SteamID uint64  `json:"steamID"`
-	Name    string  `json:"name"`
-	Team    string  `json:"team"`
-	Kills   int     `json:"kills"`
-	Deaths  int     `json:"deaths"`
-	Assists int     `json:"assists"`
-	Damage  int     `json:"damage"`
-	ADR     float64 `json:"adr"`
+	SteamID          uint64                `json:"steamID"`
+	Name             string                `json:"name"`
+	Role             string                `json:"role"` // "player" ou "bot" (com -include-bots)
+	IsBot            bool                  `json:"isBot,omitempty"`
+	Team             string                `json:"team"`                // Lado no fim da partida ("T", "CT" ou "SPEC")
+	TeamID           string                `json:"teamId,omitempty"`    // Time estável ("A" ou "B"), ver MatchTeam
+	HalfSides        []HalfSide            `json:"halfSides,omitempty"` // Lado em cada metade jogada
+	Kills            int                   `json:"kills"`
+	Deaths           int                   `json:"deaths"`
+	Assists          int                   `json:"assists"`
+	FlashAssists     int                   `json:"flashAssists"` // Assists por flash (AssistedFlash)
+	AssistDamage     int                   `json:"assistDamage"` // Dano causado nas vítimas das assists
+	HSKills          int                   `json:"hsKills"`
+	Damage           int                   `json:"damage"` // Dano em inimigos (base do ADR)
+	TeamDamage       int                   `json:"teamDamage"`
+	SelfDamage       int                   `json:"selfDamage"`
+	ScoreboardDamage int                   `json:"scoreboardDamage,omitempty"` // Dano do placar do jogo, para conferência
+	ADR              float64               `json:"adr"`
+	Rounds           int                   `json:"rounds"`              // Rounds oficiais jogados
+	Sides            map[string]*SideStats `json:"sides,omitempty"`     // "T" / "CT"
+	Weapons          []WeaponStats         `json:"weapons"`             // Por arma, mais kills primeiro
+	Aim              *AimStats             `json:"aim,omitempty"`       // Precisão e spray (armas de fogo)
+	Reaction         *ReactionStats        `json:"reaction,omitempty"`  // Tempo entre avistar o inimigo e atirar/acertar
+	Crosshair        *CrosshairStats       `json:"crosshair,omitempty"` // Erro de mira ao avistar o inimigo
+	Duels            *DuelStats            `json:"duels,omitempty"`     // Vitórias em duelos por arma e distância
+	Awareness        *AwarenessStats       `json:"awareness,omitempty"` // Tempo visto por inimigos e mortes sem ver o killer
+}
+
+// SideStats acumula os números de um jogador em um lado (T ou CT)
+type SideStats struct {
+	Rounds  int `json:"rounds"`
+	Kills   int `json:"kills"`
+	Deaths  int `json:"deaths"`
+	HSKills int `json:"hsKills"`
+	Damage  int `json:"damage"`
 }
 
 type PlayerStats struct {
-	Kills   int
-	Deaths  int
-	HSKills int
-	Damage  int
+	Kills        int // Só kills em inimigos
+	Deaths       int
+	Assists      int
+	FlashAssists int
+	AssistDamage int // Dano causado nas vítimas das assists
+	HSKills      int
+	Damage       int // Dano em inimigos
+	TeamDamage   int
+	SelfDamage   int
 }
 
 type SimpleSummary struct {
@@ -92,6 +158,7 @@ type PlayerAnalysis struct {
 	Kills           int      `json:"kills"`
 	Deaths          int      `json:"deaths"`
 	Assists         int      `json:"assists"`
+	FlashAssists    int      `json:"flashAssists"`
 	HSKills         int      `json:"hsKills"`
 	Damage          int      `json:"damage"`
 	ADR             float64  `json:"adr"`
@@ -102,53 +169,239 @@ type PlayerAnalysis struct {
 	Recommendations []string `json:"recommendations"`
 }
 
+// analyzeOptions são as opções da análise completa (CLI, batch, serve)
+type analyzeOptions struct {
+	TargetSteamID uint64               // Jogador para a análise focada (0 = nenhum)
+	OnProgress    func(ProgressRecord) // Recebe o progresso do parse (nil = desligado)
+	IncludeBots   bool                 // Inclui bots em players/teams (coaches e espectadores nunca entram)
+	OnParseEnd    func()               // Chamada quando o parse para (fim, erro ou cancelamento)
+}
+
+// Códigos de saída quando o JSON foi gerado, mas com resultado parcial
+const (
+	exitCodePartial     = 5 // Demo truncada ou corrompida (2 é panic do Go e flag inválida)
+	exitCodeInterrupted = 3 // SIGINT/SIGTERM
+	exitCodeTimeout     = 4 // --timeout excedido
+)
+
+// Tempo que o parse tem para parar depois de cancelado antes de o processo sair sem resultado
+const shutdownGrace = 10 * time.Second
+
+var (
+	// errCorruptDemo sinaliza que o parser entrou em panic por dados inválidos na demo
+	errCorruptDemo = errors.New("dados inválidos na demo")
+	// Causas de cancelamento do contexto da análise
+	errInterrupted = errors.New("análise interrompida por sinal")
+	errTimeout     = errors.New("tempo limite da análise excedido")
+)
+
 func main() {
 	if len(os.Args) < 2 {
-		fmt.Fprintf(os.Stderr, "Uso: %s <demo_path> [steam_id]\n", os.Args[0])
-		fmt.Fprintf(os.Stderr, "  demo_path: Caminho para o arquivo .dem\n")
-		fmt.Fprintf(os.Stderr, "  steam_id: (opcional) Steam ID64 do jogador para análise focada\n")
+		printUsage()
 		os.Exit(1)
 	}
 
-	demoPath := os.Args[1]
-	var targetSteamID uint64 = 0
-	if len(os.Args) >= 3 {
-		_, err := fmt.Sscanf(os.Args[2], "%d", &targetSteamID)
+	switch os.Args[1] {
+	case "info":
+		runInfo(os.Args[2:])
+		return
+	case "batch":
+		runBatch(os.Args[2:])
+		return
+	case "aggregate":
+		runAggregate(os.Args[2:])
+		return
+	case "serve":
+		runServe(os.Args[2:])
+		return
+	}
+
+	progress := flag.Bool("progress", false, "emite registros de progresso (JSON por linha) no stderr")
+	progressFD := flag.Int("progress-fd", 0, "emite os registros de progresso neste file descriptor em vez do stderr")
+	timeout := flag.Duration("timeout", 0, "tempo máximo de análise (ex.: 5m); ao estourar, grava o resultado parcial")
+	includeBots := flag.Bool("include-bots", false, "inclui bots em players e teams (marcados com isBot)")
+	logOpts := addLogFlags(flag.CommandLine)
+	flag.Usage = printUsage
+	flag.Parse()
+	logOpts.setup()
+
+	if flag.NArg() < 1 {
+		printUsage()
+		os.Exit(1)
+	}
+
+	demoPath := flag.Arg(0)
+	opts := analyzeOptions{IncludeBots: *includeBots}
+	if flag.NArg() >= 2 {
+		_, err := fmt.Sscanf(flag.Arg(1), "%d", &opts.TargetSteamID)
 		if err != nil {
-			targetSteamID = 0
+			opts.TargetSteamID = 0
 		}
 	}
 
-	f, err := os.Open(demoPath)
+	if *progressFD > 0 {
+		opts.OnProgress = jsonLinesProgress(os.NewFile(uintptr(*progressFD), "progress"))
+	} else if *progress {
+		opts.OnProgress = jsonLinesProgress(os.Stderr)
+	}
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
+	if *timeout > 0 {
+		var cancelTimeout context.CancelFunc
+		ctx, cancelTimeout = context.WithTimeoutCause(ctx, *timeout, errTimeout)
+		defer cancelTimeout()
+	}
+	opts.OnParseEnd = handleShutdown(ctx, cancel)
+
+	analysis, err := analyzeDemo(ctx, demoPath, opts)
+	if err != nil {
+		slog.Error("Erro ao processar demo", "demo", demoPath, "err", err)
+		os.Exit(1)
+	}
+
+	// Output JSON
+	jsonData, err := json.MarshalIndent(analysis, "", "  ")
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Erro ao abrir demo: %v\n", err)
+		slog.Error("Erro ao serializar JSON", "err", err)
 		os.Exit(1)
 	}
+
+	fmt.Print(string(jsonData))
+
+	if analysis.Metadata.Truncated {
+		switch context.Cause(ctx) {
+		case errInterrupted:
+			os.Exit(exitCodeInterrupted)
+		case errTimeout:
+			os.Exit(exitCodeTimeout)
+		}
+		os.Exit(exitCodePartial)
+	}
+}
+
+// handleShutdown cancela a análise em SIGINT/SIGTERM para que o resultado parcial seja gravado.
+// Se o parse não parar dentro de shutdownGrace após o cancelamento, o processo sai sem resultado.
+// A função devolvida desarma o watchdog quando o parse termina: a montagem e a escrita do JSON
+// não têm prazo, e um novo sinal a partir daí volta ao comportamento padrão (encerra o processo).
+func handleShutdown(ctx context.Context, cancel context.CancelCauseFunc) (disarm func()) {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+
+	parseEnded := make(chan struct{})
+	var once sync.Once
+	disarm = func() {
+		once.Do(func() {
+			signal.Stop(sigs)
+			close(parseEnded)
+		})
+	}
+
+	go func() {
+		select {
+		case sig := <-sigs:
+			slog.Warn("Sinal recebido, gravando resultado parcial", "signal", sig.String())
+			cancel(errInterrupted)
+		case <-ctx.Done():
+		case <-parseEnded:
+			return
+		}
+
+		select {
+		case <-time.After(shutdownGrace):
+		case <-parseEnded:
+			return
+		}
+		slog.Error("Análise não parou após o cancelamento", "cause", context.Cause(ctx))
+		if context.Cause(ctx) == errTimeout {
+			os.Exit(exitCodeTimeout)
+		}
+		os.Exit(exitCodeInterrupted)
+	}()
+	return disarm
+}
+
+func printUsage() {
+	fmt.Fprintf(os.Stderr, "Uso: %s [-progress] [-progress-fd n] [-timeout d] [-include-bots] <demo_path> [steam_id]\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "     %s info <demo_path>\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "     %s batch [-out dir] [-workers n] <diretório|glob>\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "     %s aggregate [-steamid id] [-out arquivo] <resultado.json|demo|diretório|glob>...\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "     %s serve [-addr host:porta] [-workers n] [-queue n]\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "  demo_path: Caminho para o arquivo .dem (aceita .dem.gz/.bz2/.zst; \"-\" lê da entrada padrão)\n")
+	fmt.Fprintf(os.Stderr, "  steam_id: (opcional) Steam ID64 do jogador para análise focada\n")
+	fmt.Fprintf(os.Stderr, "  info: apenas metadados do cabeçalho (mapa, servidor, tick rate, jogadores)\n")
+	fmt.Fprintf(os.Stderr, "  batch: analisa todas as demos de um diretório, um JSON por demo + index.json\n")
+	fmt.Fprintf(os.Stderr, "  aggregate: soma várias partidas em estatísticas de carreira por SteamID\n")
+	fmt.Fprintf(os.Stderr, "  serve: servidor HTTP com fila de análises (para o backend Node chamar em localhost)\n")
+	fmt.Fprintf(os.Stderr, "  -progress: progresso do parse em JSON por linha no stderr (-progress-fd para outro fd)\n")
+	fmt.Fprintf(os.Stderr, "  -timeout: tempo máximo de análise (ex.: 5m)\n")
+	fmt.Fprintf(os.Stderr, "  -include-bots: inclui bots nas estatísticas (coaches e espectadores nunca entram)\n")
+	fmt.Fprintf(os.Stderr, "  -log-level debug|info|warn|error, -log-format text|json: logs no stderr (todos os comandos)\n")
+	fmt.Fprintf(os.Stderr, "  -parser-log arquivo, -parser-log-level: avisos do parser (demoinfocs) em canal separado\n")
+	fmt.Fprintf(os.Stderr, "Códigos de saída: 0 ok, 1 erro, 2 uso inválido ou panic, %d interrompido (SIGINT/SIGTERM), %d timeout, %d parcial (demo truncada)\n",
+		exitCodeInterrupted, exitCodeTimeout, exitCodePartial)
+}
+
+// analyzeDemo processa a demo inteira e monta a análise.
+// Se o parse falhar no meio (demo truncada ou corrompida), retorna o que foi
+// analisado até a falha com Metadata.Truncated e a descrição do erro.
+// Cancelar o ctx interrompe o parse e também devolve o resultado parcial.
+func analyzeDemo(ctx context.Context, demoPath string, opts analyzeOptions) (*SimpleAnalysis, error) {
+	f, err := openDemo(demoPath)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao abrir demo: %w", err)
+	}
 	defer f.Close()
 
-	p := demoinfocs.NewParser(f)
+	var progress *progressTracker
+	if opts.OnProgress != nil {
+		progress = newProgressTracker(opts.OnProgress, demoPlaybackTicks(f))
+	}
+
+	p, err := newDemoParser(f)
+	if err != nil {
+		return nil, err
+	}
 	defer p.Close()
 
+	logger := slog.With("demo", demoPath)
+
 	analysis := &SimpleAnalysis{
-		Events:  []DetailedEvent{},
-		Players: []SimplePlayer{},
-		Heatmap: HeatmapData{Points: []HeatmapPoint{}},
+		Events:    []DetailedEvent{},
+		Players:   []SimplePlayer{},
+		Heatmap:   HeatmapData{Points: []HeatmapPoint{}},
+		Crosshair: []CrosshairEngagement{},
+		Duels:     []Duel{},
 	}
 
 	// Variáveis de tracking
 	playerMap := make(map[uint64]*SimplePlayer)
-	playerStats := make(map[uint64]*PlayerStats)
+	stats := newStatsAccumulator()
+	aim := newAimTracker()
+	engagements := newEngagementTracker()
+	duels := newDuelTracker()
 	heatmapPoints := make(map[string]*HeatmapPoint)
 
-	var currentRound int = 0
-	var isGC bool = false
-	var officialRoundStart int = -1
-	warmupRounds := make(map[int]bool)
-	knifeRounds := make(map[int]bool)
-	roundKills := make(map[int]int)
-	roundKnifeKills := make(map[int]int)
+	phases := newMatchPhases()
+	warmupRounds, knifeRounds := phases.warmup, phases.knife
+	roundTeamsSeen := make(map[int]roundTeams)
+	var knifeResult *KnifeRoundResult
+	knifeWinners := make(map[uint64]bool) // SteamIDs do time que venceu a faca
 	roundScores := make(map[int]map[string]int)
 
+	// Round de faca com dinheiro fica pendente até uma kill com outra arma ou o fim do round.
+	// official aplica o efeito em round oficial, guarda no round pendente e descarta em
+	// round de faca confirmado.
+	pendingKnife := &pendingKnifeRound{}
+	official := func(effect func()) {
+		switch {
+		case pendingKnife.active(phases.current):
+			pendingKnife.add(effect)
+		case !knifeRounds[phases.current]:
+			effect()
+		}
+	}
+
 	startTime := time.Now()
 
 	// Função auxiliar
@@ -176,18 +429,20 @@ func main() {
 		point.Intensity++
 	}
 
-	updatePlayer := func(p *common.Player, isKill, isDeath, isAssist bool) {
+	// updatePlayer registra o participante (nome, lado atual e papel); os números ficam em stats
+	updatePlayer := func(p *common.Player) {
 		if p == nil {
 			return
 		}
-		player, exists := playerMap[p.SteamID64]
+		player, exists := playerMap[participantID(p)]
 		if !exists {
 			player = &SimplePlayer{
-				SteamID: p.SteamID64,
+				SteamID: participantID(p),
 				Name:    p.Name,
 				Team:    teamToString(p.Team),
+				IsBot:   p.IsBot,
 			}
-			playerMap[p.SteamID64] = player
+			playerMap[participantID(p)] = player
 		} else {
 			// Atualizar time e nome (pode mudar durante a partida)
 			player.Team = teamToString(p.Team)
@@ -195,53 +450,65 @@ func main() {
 				player.Name = p.Name
 			}
 		}
-		if isKill {
-			player.Kills++
-		}
-		if isDeath {
-			player.Deaths++
-		}
-		if isAssist {
-			player.Assists++
-		}
+		player.Role = mergeRole(player.Role, participantRole(p))
 	}
 
-	updatePlayerStats := func(p *common.Player, isHS, isKill, isDeath bool) {
-		if p == nil {
+	// Mapa do cabeçalho da demo (demos do GC/MM não têm o mapa no nome do arquivo)
+	headerMap := ""
+	p.RegisterNetMessageHandler(func(m *msg.CDemoFileHeader) {
+		headerMap = m.GetMapName()
+	})
+
+	// Avisos não fatais do parser (entidades faltando, mensagens desconhecidas...)
+	diagnostics := newParserDiagnostics()
+	p.RegisterEventHandler(func(e events.ParserWarn) {
+		tick := p.GameState().IngameTick()
+		diagnostics.add(e, phases.current, tick)
+		parserLog.Warn(e.Message, "demo", demoPath, "kind", warnKind(e.Type), "round", phases.current, "tick", tick)
+	})
+
+	// Restart da partida: o que foi jogado antes não vale. Em GC são os rounds de
+	// aquecimento/faca jogados antes do "live". Vários sinais podem marcar o mesmo restart
+	// (begin_new_match, m_bHasMatchStarted, fim do warmup); o segundo não descarta nada.
+	matchRestarted := func(e any) {
+		signal, ok := restartSignal(e)
+		if !ok {
 			return
 		}
-		stats, exists := playerStats[p.SteamID64]
-		if !exists {
-			stats = &PlayerStats{}
-			playerStats[p.SteamID64] = stats
-		}
-		if isHS {
-			stats.HSKills++
-		}
-		if isKill {
-			stats.Kills++
-		}
-		if isDeath {
-			stats.Deaths++
-		}
-	}
-
-	updatePlayerDamage := func(p *common.Player, damage int) {
-		if p == nil {
+		gs := p.GameState()
+		lastPreMatch, discarded := phases.restart(gs)
+		if len(discarded) == 0 {
 			return
 		}
-		stats, exists := playerStats[p.SteamID64]
-		if !exists {
-			stats = &PlayerStats{}
-			playerStats[p.SteamID64] = stats
+		pendingKnife.discard()
+
+		// Stats acumulados nesses rounds não contam para a partida
+		clear(playerMap)
+		stats.reset()
+		aim.reset()
+		engagements.reset()
+		duels.reset()
+		clear(heatmapPoints)
+		for i := range analysis.Events {
+			if analysis.Events[i].Round <= lastPreMatch {
+				analysis.Events[i].IsWarmup = true
+			}
 		}
-		stats.Damage += damage
+		logger.Debug("Restart da partida: rounds anteriores descartados", "signal", signal, "round", phases.current,
+			"tick", gs.IngameTick(), "discarded", len(discarded))
 	}
 
+	// Algumas demos não têm o begin_new_match: a partida começa quando m_bHasMatchStarted
+	// vira true ou quando o warmup acaba
+	p.RegisterEventHandler(func(e events.MatchStart) { matchRestarted(e) })
+	p.RegisterEventHandler(func(e events.MatchStartedChanged) { matchRestarted(e) })
+	p.RegisterEventHandler(func(e events.IsWarmupPeriodChanged) { matchRestarted(e) })
+
 	// RoundStart
 	p.RegisterEventHandler(func(e events.RoundStart) {
-		currentRound++
 		gs := p.GameState()
+		isWarmupRound := phases.roundStart(gs)
+		stats.newRound()
 		ctScore := 0
 		tScore := 0
 		if gs != nil {
@@ -253,35 +520,9 @@ func main() {
 			}
 		}
 
-		// DETECÇÃO DE GC: Se rounds 1-4 têm score 0-0, é GC
-		if currentRound <= 4 && ctScore == 0 && tScore == 0 {
-			isGC = true
-		}
-
-		// LÓGICA DE WARMUP:
-		// GC: rounds 1-4 são SEMPRE warmup
-		// MM: round 0 é warmup, e rounds com score 0-0 são warmup
-		isWarmupRound := false
-		if currentRound == 0 {
-			isWarmupRound = true
-		} else if isGC && currentRound <= 4 {
-			isWarmupRound = true
-		}
-
-		if isWarmupRound {
-			warmupRounds[currentRound] = true
-		}
-
-		// Marcar primeiro round oficial
-		// GC: round 5 é o primeiro oficial
-		// MM: primeiro round com score > 0 ou após warmup
-		if !isWarmupRound && officialRoundStart == -1 {
-			officialRoundStart = currentRound
-		}
-
-		roundKills[currentRound] = 0
-		roundKnifeKills[currentRound] = 0
-		roundScores[currentRound] = map[string]int{"CT": ctScore, "T": tScore}
+		roundScores[phases.current] = map[string]int{"CT": ctScore, "T": tScore}
+		logger.Debug("Round iniciado", "round", phases.current, "tick", p.GameState().IngameTick(),
+			"scoreCT", ctScore, "scoreT", tScore, "warmup", isWarmupRound)
 
 		event := DetailedEvent{
 			Type: "round_start",
@@ -292,13 +533,53 @@ func main() {
 				}
 				return 0
 			}(),
-			Round:    currentRound,
+			Round:    phases.current,
 			IsWarmup: isWarmupRound,
-			Data:     map[string]interface{}{"round": currentRound},
+			Data:     map[string]interface{}{"round": phases.current},
 		}
 		analysis.Events = append(analysis.Events, event)
 	})
 
+	// Fim do freezetime: loadouts já definidos, identifica round de faca e resolve
+	// o lado escolhido por quem venceu a faca anterior
+	p.RegisterEventHandler(func(e events.RoundFreezetimeEnd) {
+		gs := p.GameState()
+		knifeOnly, broke := knifeLoadout(gs.Participants().Playing())
+		if !phases.freezetimeEnd(gs, knifeOnly) {
+			if knifeRounds[phases.current] {
+				if !broke {
+					pendingKnife.start(phases.current)
+				}
+				logger.Debug("Round de faca identificado", "round", phases.current, "tick", gs.IngameTick(), "pending", !broke)
+			}
+			return
+		}
+		roundTeamsSeen[phases.current] = observeRoundTeams(gs, gs.Participants().Playing())
+
+		if knifeResult != nil && knifeResult.ChosenSide == "" {
+			sides := map[common.Team]int{}
+			for _, player := range gs.Participants().Playing() {
+				if player != nil && knifeWinners[participantID(player)] {
+					sides[player.Team]++
+				}
+			}
+			chosen := common.TeamTerrorists
+			if sides[common.TeamCounterTerrorists] > sides[common.TeamTerrorists] {
+				chosen = common.TeamCounterTerrorists
+			}
+			if sides[chosen] > 0 {
+				knifeResult.ChosenSide = teamToString(chosen)
+				knifeResult.Switched = knifeResult.ChosenSide != knifeResult.Winner
+			}
+		}
+	})
+
+	// Troca de lado (halftime e prorrogação), disparada logo depois do RoundStart
+	p.RegisterEventHandler(func(e events.TeamSideSwitch) {
+		phases.sideSwitched()
+		logger.Debug("Troca de lado", "round", phases.current, "tick", p.GameState().IngameTick())
+	})
+
 	// RoundEnd
 	p.RegisterEventHandler(func(e events.RoundEnd) {
 		winner := "T"
@@ -306,25 +587,47 @@ func main() {
 			winner = "CT"
 		}
 
-		gs := p.GameState()
-		knifeKills := roundKnifeKills[currentRound]
-		totalKills := roundKills[currentRound]
-		isKnifeRound := knifeKills >= 3 && totalKills > 0 && float64(knifeKills)/float64(totalKills) > 0.5
+		// Round de faca pendente que terminou só com kills de faca: confirmado
+		if pendingKnife.active(phases.current) {
+			logger.Debug("Round de faca confirmado", "round", phases.current, "discarded", pendingKnife.discard())
+		}
 
-		if isKnifeRound {
-			knifeRounds[currentRound] = true
+		gs := p.GameState()
+		isKnifeRound := knifeRounds[phases.current]
+		isWarmupRound := warmupRounds[phases.current]
+
+		// Vencedor da faca: o lado escolhido é resolvido no próximo fim de freezetime
+		if isKnifeRound && (e.Winner == common.TeamTerrorists || e.Winner == common.TeamCounterTerrorists) {
+			knifeResult = &KnifeRoundResult{Round: phases.current, Winner: winner}
+			if team := gs.Team(e.Winner); team != nil {
+				knifeResult.WinnerTeam = team.ClanName()
+			}
+			clear(knifeWinners)
+			for _, player := range gs.Participants().Playing() {
+				if player != nil && participantID(player) > 0 && player.Team == e.Winner {
+					knifeWinners[participantID(player)] = true
+				}
+			}
 		}
+		engagements.closeAll()
+		duels.closeAll()
 
-		// IMPORTANTE: Para GC, rounds 1-4 são SEMPRE warmup
-		isWarmupRound := warmupRounds[currentRound]
-		if isGC && currentRound <= 4 {
-			isWarmupRound = true
-			warmupRounds[currentRound] = true
+		if info := phases.roundEnd(gs, e.Winner); info != nil {
+			if _, exists := roundTeamsSeen[phases.current]; !exists {
+				roundTeamsSeen[phases.current] = observeRoundTeams(gs, gs.Participants().Playing())
+			}
 		}
 
-		// Marcar primeiro round oficial
-		if officialRoundStart == -1 && !isWarmupRound && !isKnifeRound {
-			officialRoundStart = currentRound
+		logger.Debug("Round encerrado", "round", phases.current, "tick", p.GameState().IngameTick(),
+			"winner", winner, "warmup", isWarmupRound, "knife", isKnifeRound)
+
+		// Rounds jogados por lado (quem estava em campo no fim do round)
+		if !isWarmupRound && !isKnifeRound && gs != nil {
+			for _, player := range gs.Participants().Playing() {
+				if player != nil && participantID(player) > 0 && !isCoach(player) {
+					stats.roundPlayed(player)
+				}
+			}
 		}
 
 		event := DetailedEvent{
@@ -336,11 +639,11 @@ func main() {
 				}
 				return 0
 			}(),
-			Round:    currentRound,
+			Round:    phases.current,
 			IsWarmup: isWarmupRound,
 			IsKnife:  isKnifeRound,
 			Data: map[string]interface{}{
-				"round":  currentRound,
+				"round":  phases.current,
 				"winner": winner,
 				"reason": int(e.Reason),
 			},
@@ -351,38 +654,35 @@ func main() {
 	// Kill
 	p.RegisterEventHandler(func(e events.Kill) {
 		gs := p.GameState()
-		isWarmupRound := warmupRounds[currentRound]
-		isKnifeRound := knifeRounds[currentRound]
-
-		// IMPORTANTE: Para GC, sempre ignorar rounds 1-4 (são warmup)
-		// Não processar kills, stats ou criar eventos para rounds de warmup GC
-		if isGC && currentRound <= 4 {
-			return // Não processar kills em rounds de warmup GC
+		isWarmupRound := warmupRounds[phases.current] || isWarmupState(gs)
+		// Round de faca pendente: uma kill com outra arma (comprada depois do freezetime)
+		// torna o round oficial e aplica o que foi guardado até aqui
+		if pendingKnife.active(phases.current) && !isKnifeRoundKill(e.Weapon) {
+			delete(knifeRounds, phases.current)
+			logger.Debug("Kill com arma em round de faca, round volta a ser oficial",
+				"round", phases.current, "weapon", weaponName(e.Weapon), "buffered", len(pendingKnife.effects))
+			pendingKnife.commit()
 		}
 
-		// Só processar kills em rounds oficiais (não warmup, não knife)
-		if isWarmupRound || isKnifeRound {
+		// Kills do warmup não contam; round de faca é resolvido por official
+		if isWarmupRound {
 			return
 		}
 
-		roundKills[currentRound]++
-		weaponStr := "unknown"
-		if e.Weapon != nil {
-			weaponStr = e.Weapon.Type.String()
-		}
-		if weaponStr == "Knife" || weaponStr == "knife" || weaponStr == "Bayonet" {
-			roundKnifeKills[currentRound]++
+		weaponStr := weaponName(e.Weapon)
+		killTime := p.CurrentTime().Seconds()
+		killTick := 0
+		if gs != nil {
+			killTick = gs.IngameTick()
 		}
 
 		killerPos := Position{}
 		victimPos := Position{}
 		if e.Killer != nil {
 			killerPos = getPosition(e.Killer)
-			addHeatmapPoint(killerPos, "kill")
 		}
 		if e.Victim != nil {
 			victimPos = getPosition(e.Victim)
-			addHeatmapPoint(victimPos, "death")
 		}
 
 		// Capturar time atual do player no momento da kill
@@ -395,107 +695,138 @@ func main() {
 			victimTeam = teamToString(e.Victim.Team)
 		}
 
-		event := DetailedEvent{
-			Type: "kill",
-			Time: p.CurrentTime().Seconds(),
-			Tick: func() int {
-				if gs != nil {
-					return gs.IngameTick()
-				}
-				return 0
-			}(),
-			Round:    currentRound,
-			IsWarmup: isWarmupRound,
-			IsKnife:  isKnifeRound,
-			Data: map[string]interface{}{
-				"killer": map[string]interface{}{
-					"name": func() string {
-						if e.Killer != nil {
-							return e.Killer.Name
-						}
-						return ""
-					}(),
-					"steamID": func() uint64 {
-						if e.Killer != nil {
-							return e.Killer.SteamID64
-						}
-						return 0
-					}(),
-					"position": killerPos,
-					"team":     killerTeam,
-				},
-				"victim": map[string]interface{}{
-					"name": func() string {
-						if e.Victim != nil {
-							return e.Victim.Name
+		round := phases.current
+		official(func() {
+			if e.Killer != nil {
+				addHeatmapPoint(killerPos, "kill")
+			}
+			if e.Victim != nil {
+				addHeatmapPoint(victimPos, "death")
+			}
+
+			event := DetailedEvent{
+				Type:     "kill",
+				Time:     killTime,
+				Tick:     killTick,
+				Round:    round,
+				IsWarmup: isWarmupRound,
+				Data: map[string]interface{}{
+					"killer": map[string]interface{}{
+						"name": func() string {
+							if e.Killer != nil {
+								return e.Killer.Name
+							}
+							return ""
+						}(),
+						"steamID": func() uint64 {
+							if e.Killer != nil {
+								return participantID(e.Killer)
+							}
+							return 0
+						}(),
+						"position": killerPos,
+						"team":     killerTeam,
+					},
+					"victim": map[string]interface{}{
+						"name": func() string {
+							if e.Victim != nil {
+								return e.Victim.Name
+							}
+							return ""
+						}(),
+						"steamID": func() uint64 {
+							if e.Victim != nil {
+								return participantID(e.Victim)
+							}
+							return 0
+						}(),
+						"position": victimPos,
+						"team":     victimTeam,
+					},
+					"assister": func() map[string]interface{} {
+						if e.Assister == nil {
+							return nil
 						}
-						return ""
-					}(),
-					"steamID": func() uint64 {
-						if e.Victim != nil {
-							return e.Victim.SteamID64
+						return map[string]interface{}{
+							"name":    e.Assister.Name,
+							"steamID": participantID(e.Assister),
+							"flash":   e.AssistedFlash,
+							"damage":  stats.assistDamage(e.Assister, e.Victim),
 						}
-						return 0
 					}(),
-					"position": victimPos,
-					"team":     victimTeam,
+					"headshot": e.IsHeadshot,
+					"weapon":   weaponStr,
 				},
-				"assister": func() string {
-					if e.Assister != nil {
-						return e.Assister.Name
-					}
-					return ""
-				}(),
-				"headshot": e.IsHeadshot,
-				"weapon":   weaponStr,
-			},
-		}
-		analysis.Events = append(analysis.Events, event)
-
-		// Atualizar stats (já estamos em round oficial, então atualizar)
-		if e.Killer != nil {
-			updatePlayer(e.Killer, true, false, false)
-			if e.IsHeadshot {
-				updatePlayerStats(e.Killer, true, true, false)
-			} else {
-				updatePlayerStats(e.Killer, false, true, false)
 			}
-		}
-		if e.Victim != nil {
-			updatePlayer(e.Victim, false, true, false)
-			updatePlayerStats(e.Victim, false, false, true)
-		}
-		if e.Assister != nil {
-			updatePlayer(e.Assister, false, false, true)
-		}
+			analysis.Events = append(analysis.Events, event)
+
+			// Atualizar stats (já estamos em round oficial, então atualizar)
+			updatePlayer(e.Killer)
+			updatePlayer(e.Victim)
+			updatePlayer(e.Assister)
+			stats.kill(e.Killer, e.Victim, e.Assister, weaponStr, e.IsHeadshot, e.AssistedFlash)
+			duels.kill(e.Killer, e.Victim, killTick, killTime)
+			engagements.death(e.Killer, e.Victim)
+		})
 	})
 
 	// PlayerHurt (para damage)
 	p.RegisterEventHandler(func(e events.PlayerHurt) {
-		isWarmupRound := warmupRounds[currentRound]
-		isKnifeRound := knifeRounds[currentRound]
-
-		if isGC && currentRound <= 4 {
+		if warmupRounds[phases.current] || isWarmupState(p.GameState()) {
 			return
 		}
-		if isWarmupRound || isKnifeRound {
+
+		// Sem atacante é dano do mundo (queda, bomba). Atacante morto conta:
+		// molotov/HE depois da morte e trocas no mesmo tick
+		if e.Attacker == nil || e.Player == nil {
 			return
 		}
+		round, tick, now := phases.current, p.GameState().IngameTick(), p.CurrentTime().Seconds()
+		official(func() {
+			stats.damage(e.Attacker, e.Player, e.Weapon, healthDamageTaken(e), tick)
+			if e.Attacker.Team != e.Player.Team {
+				aim.hit(e.Attacker, e.Weapon, tick)
+				engagements.damage(e.Attacker, e.Player, now)
+				duels.hurt(e.Attacker, e.Player, e.Weapon, healthDamageTaken(e), round, tick, now)
+			}
+		})
+	})
 
-		if e.Attacker != nil && e.Attacker.IsAlive() {
-			updatePlayerDamage(e.Attacker, e.HealthDamage)
+	// WeaponFire (disparos para a precisão por arma)
+	p.RegisterEventHandler(func(e events.WeaponFire) {
+		if warmupRounds[phases.current] || isWarmupState(p.GameState()) {
+			return
+		}
+		if e.Shooter == nil {
+			return
 		}
+		// Visão e velocidade são lidas agora, mesmo se o disparo ficar guardado
+		now := p.CurrentTime().Seconds()
+		shot := aim.capture(e.Shooter, e.Weapon, p.GameState().IngameTick(), now)
+		official(func() {
+			stats.shot(e.Shooter, e.Weapon)
+			if shot != nil {
+				aim.record(participantID(e.Shooter), shot)
+			}
+			engagements.shot(e.Shooter, e.Weapon, now)
+		})
 	})
 
-	// BombPlanted
-	p.RegisterEventHandler(func(e events.BombPlanted) {
-		isWarmupRound := warmupRounds[currentRound]
-		isKnifeRound := knifeRounds[currentRound]
+	// Posição dos jogadores a cada frame (velocidade no momento dos disparos) e
+	// inimigos avistados com o round rolando (tempo de reação)
+	p.RegisterEventHandler(func(e events.FrameDone) {
+		gs := p.GameState()
+		aim.sample(gs.Participants().Playing(), p.CurrentTime().Seconds())
 
-		if isGC && currentRound <= 4 {
+		if !phases.inProgress || gs.IsFreezetimePeriod() || warmupRounds[phases.current] || knifeRounds[phases.current] || isWarmupState(gs) {
 			return
 		}
-		if isWarmupRound || isKnifeRound {
+		engagements.observe(gs.Participants().Playing(), phases.current, p.CurrentTime().Seconds(), gs.IngameTick())
+	})
+
+	// BombPlanted
+	p.RegisterEventHandler(func(e events.BombPlanted) {
+		if warmupRounds[phases.current] || isWarmupState(p.GameState()) {
 			return
 		}
 
@@ -504,7 +835,6 @@ func main() {
 		}
 
 		playerPos := getPosition(e.Player)
-		addHeatmapPoint(playerPos, "bomb_planted")
 
 		event := DetailedEvent{
 			Type: "bomb_planted",
@@ -516,29 +846,26 @@ func main() {
 				}
 				return 0
 			}(),
-			Round: currentRound,
+			Round: phases.current,
 			Data: map[string]interface{}{
 				"player": map[string]interface{}{
 					"name":     e.Player.Name,
-					"steamID":  e.Player.SteamID64,
+					"steamID":  participantID(e.Player),
 					"position": playerPos,
 				},
 				"site":  "unknown",
 				"timer": 40.0,
 			},
 		}
-		analysis.Events = append(analysis.Events, event)
+		official(func() {
+			addHeatmapPoint(playerPos, "bomb_planted")
+			analysis.Events = append(analysis.Events, event)
+		})
 	})
 
 	// BombDefused
 	p.RegisterEventHandler(func(e events.BombDefused) {
-		isWarmupRound := warmupRounds[currentRound]
-		isKnifeRound := knifeRounds[currentRound]
-
-		if isGC && currentRound <= 4 {
-			return
-		}
-		if isWarmupRound || isKnifeRound {
+		if warmupRounds[phases.current] || isWarmupState(p.GameState()) {
 			return
 		}
 
@@ -557,62 +884,70 @@ func main() {
 				}
 				return 0
 			}(),
-			Round: currentRound,
+			Round: phases.current,
 			Data: map[string]interface{}{
 				"player": map[string]interface{}{
 					"name":     e.Player.Name,
-					"steamID":  e.Player.SteamID64,
+					"steamID":  participantID(e.Player),
 					"position": playerPos,
 				},
 			},
 		}
-		analysis.Events = append(analysis.Events, event)
+		official(func() {
+			analysis.Events = append(analysis.Events, event)
+		})
 	})
 
+	if progress != nil {
+		p.RegisterEventHandler(func(e events.FrameDone) {
+			progress.update(p.Progress(), p.GameState().IngameTick(), phases.current)
+		})
+	}
+
 	// Parsear demo
-	err = p.ParseToEnd()
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Erro ao parsear demo: %v\n", err)
-		os.Exit(1)
+	parseErr := parseToEnd(ctx, p)
+	if opts.OnParseEnd != nil {
+		opts.OnParseEnd()
+	}
+	if progress != nil {
+		progress.done(p.Progress(), p.GameState().IngameTick(), phases.current, parseErr == nil)
+	}
+	if parseErr != nil {
+		// Sem nenhum frame parseado não há resultado parcial para devolver
+		if errors.Is(parseErr, demoinfocs.ErrInvalidFileType) || p.CurrentFrame() == 0 {
+			return nil, fmt.Errorf("erro ao parsear demo: %w", parseErr)
+		}
+		analysis.Metadata.Truncated = true
+		analysis.Metadata.Error = describeParseError(parseErr)
 	}
 
 	duration := time.Since(startTime)
 	gs := p.GameState()
 	if gs == nil {
-		fmt.Fprintf(os.Stderr, "Erro: GameState não disponível\n")
-		os.Exit(1)
+		return nil, errors.New("GameState não disponível")
 	}
 
-	// Coletar TODOS os players do GameState
+	// Demo que termina no meio do round ainda tem contatos e duelos abertos
+	engagements.closeAll()
+	duels.closeAll()
+
+	// Coletar todos os participantes; coaches, espectadores e bots saem na montagem de players
+	scoreboardDamage := make(map[uint64]int)
 	for _, player := range gs.Participants().All() {
-		if player != nil && player.SteamID64 > 0 {
-			updatePlayer(player, false, false, false)
-		}
-	}
-
-	// Detectar mapa
-	mapName := "unknown"
-	if demoPath != "" {
-		pathLower := strings.ToLower(demoPath)
-		if strings.Contains(pathLower, "de_mirage") {
-			mapName = "de_mirage"
-		} else if strings.Contains(pathLower, "de_dust2") || strings.Contains(pathLower, "dust2") {
-			mapName = "de_dust2"
-		} else if strings.Contains(pathLower, "de_inferno") {
-			mapName = "de_inferno"
-		} else if strings.Contains(pathLower, "de_ancient") {
-			mapName = "de_ancient"
-		} else if strings.Contains(pathLower, "de_vertigo") {
-			mapName = "de_vertigo"
-		} else if strings.Contains(pathLower, "de_anubis") {
-			mapName = "de_anubis"
-		} else if strings.Contains(pathLower, "de_overpass") {
-			mapName = "de_overpass"
-		} else if strings.Contains(pathLower, "de_nuke") {
-			mapName = "de_nuke"
+		if player != nil && participantID(player) > 0 {
+			updatePlayer(player)
+			if damage, ok := scoreboardDamageOf(player); ok {
+				scoreboardDamage[participantID(player)] = damage
+			}
 		}
 	}
 
+	// Mapa do cabeçalho; o nome do arquivo fica só como alternativa
+	mapName := headerMap
+	if mapName == "" {
+		mapName = mapFromPath(demoPath)
+	}
+
 	scoreT := 0
 	scoreCT := 0
 	if gs.TeamTerrorists() != nil {
@@ -626,7 +961,7 @@ func main() {
 	officialRounds := 0
 	warmupCount := 0
 	hasKnifeRound := false
-	for r := 0; r <= currentRound; r++ {
+	for r := 1; r <= phases.current; r++ {
 		if warmupRounds[r] {
 			warmupCount++
 		} else if knifeRounds[r] {
@@ -636,25 +971,44 @@ func main() {
 		}
 	}
 
-	// Se GC, garantir que rounds 1-4 sejam contados como warmup
-	if isGC && warmupCount < 4 {
-		warmupCount = 4
-	}
+	firstOfficialRound := phases.firstOfficialRound()
 
+	// Servidores GC jogam rounds fora do aquecimento e dão restart antes de valer;
+	// no MM oficial a partida começa direto do warmup
 	source := "Valve"
-	if isGC {
+	if phases.preMatch > 0 {
 		source = "GC"
 	}
 
-	analysis.Metadata = MatchMetadata{
-		Map:          mapName,
-		Duration:     formatDuration(duration),
-		Rounds:       officialRounds,
-		ScoreT:       scoreT,
-		ScoreCT:      scoreCT,
-		WarmupRounds: warmupCount,
-		KnifeRound:   hasKnifeRound,
-		Source:       source,
+	analysis.Metadata.Map = mapName
+	analysis.Metadata.Duration = formatDuration(duration)
+	analysis.Metadata.Rounds = officialRounds
+	analysis.Metadata.ScoreT = scoreT
+	analysis.Metadata.ScoreCT = scoreCT
+	analysis.Metadata.WarmupRounds = warmupCount
+	analysis.Metadata.FirstOfficialRound = firstOfficialRound
+	analysis.Metadata.KnifeRound = hasKnifeRound
+	analysis.Metadata.Knife = knifeResult
+	analysis.Metadata.Source = source
+	analysis.Metadata.Diagnostics = diagnostics
+
+	// Metades e prorrogações: rounds descartados por restart ficam de fora
+	analysis.Rounds = phases.official()
+	regulation, overtimes := phases.rounds.periodScores(analysis.Rounds)
+	analysis.Metadata.Overtimes = len(overtimes)
+	analysis.Metadata.Regulation = regulation
+	analysis.Metadata.OvertimeScores = overtimes
+	for i := range analysis.Events {
+		event := &analysis.Events[i]
+		info, exists := phases.rounds.rounds[event.Round]
+		if !exists || event.IsWarmup || event.IsKnife || warmupRounds[event.Round] {
+			continue
+		}
+		event.Half = info.Half
+		event.Overtime = info.Overtime
+		if event.Type == "round_start" && info.SideSwap && event.Data != nil {
+			event.Data["sideSwap"] = true
+		}
 	}
 
 	// Converter heatmap
@@ -663,29 +1017,96 @@ func main() {
 		analysis.Heatmap.Points = append(analysis.Heatmap.Points, *point)
 	}
 
+	// Times A/B estáveis entre as trocas de lado
+	teams, playerTeams := buildTeams(analysis.Rounds, roundTeamsSeen)
+	analysis.Teams = teams
+
 	// Adicionar damage e ADR aos players
 	for _, player := range playerMap {
-		stats, hasStats := playerStats[player.SteamID]
-		if hasStats {
-			player.Damage = stats.Damage
-			if officialRounds > 0 {
-				player.ADR = float64(stats.Damage) / float64(officialRounds)
+		// Quem jogou rounds oficiais é jogador mesmo que tenha terminado como espectador
+		if _, played := playerTeams[player.SteamID]; played && player.Role == roleSpectator {
+			player.Role = rolePlayer
+		}
+		if player.Role != rolePlayer && !(player.Role == roleBot && opts.IncludeBots) {
+			continue
+		}
+		// Conferência com o placar do jogo: diferença grande indica evento perdido ou demo danificada
+		if damage, exists := scoreboardDamage[player.SteamID]; exists {
+			player.ScoreboardDamage = damage
+		}
+		if team, exists := playerTeams[player.SteamID]; exists {
+			player.TeamID = team.TeamID
+			player.HalfSides = team.HalfSides
+		}
+		playerStats := stats.get(player.SteamID)
+		player.Kills = playerStats.Kills
+		player.Deaths = playerStats.Deaths
+		player.Assists = playerStats.Assists
+		player.FlashAssists = playerStats.FlashAssists
+		player.AssistDamage = playerStats.AssistDamage
+		player.HSKills = playerStats.HSKills
+		player.Damage = playerStats.Damage
+		player.TeamDamage = playerStats.TeamDamage
+		player.SelfDamage = playerStats.SelfDamage
+		if officialRounds > 0 {
+			player.ADR = float64(playerStats.Damage) / float64(officialRounds)
+		}
+		player.Weapons = sortedWeapons(stats.weapons[player.SteamID])
+		player.Aim = aim.stats(player.SteamID)
+		player.Reaction = engagements.stats(player.SteamID)
+		player.Crosshair = engagements.crosshair(player.SteamID)
+		player.Duels = duels.stats(player.SteamID)
+		player.Awareness = engagements.awarenessStats(player.SteamID)
+		if sides, hasSides := stats.sides[player.SteamID]; hasSides {
+			player.Sides = sides
+			for _, side := range sides {
+				player.Rounds += side.Rounds
 			}
 		}
+		if diff := player.Damage - player.ScoreboardDamage; player.ScoreboardDamage > 0 && (diff > 10 || diff < -10) {
+			logger.Warn("Dano diferente do placar do jogo", "steamID", player.SteamID, "player", player.Name,
+				"damage", player.Damage, "scoreboard", player.ScoreboardDamage)
+		}
 		analysis.Players = append(analysis.Players, *player)
 	}
 
+	// Listas dos times só com quem entrou em players
+	for i := range analysis.Teams {
+		analysis.Teams[i].Players = []uint64{}
+		for _, player := range analysis.Players {
+			if player.TeamID == analysis.Teams[i].ID {
+				analysis.Teams[i].Players = append(analysis.Teams[i].Players, player.SteamID)
+			}
+		}
+		sort.Slice(analysis.Teams[i].Players, func(a, b int) bool {
+			return analysis.Teams[i].Players[a] < analysis.Teams[i].Players[b]
+		})
+	}
+
+	// Mira por contato, só dos participantes que entraram em players
+	included := make(map[uint64]bool)
+	for _, player := range analysis.Players {
+		included[player.SteamID] = true
+	}
+	analysis.Crosshair = []CrosshairEngagement{}
+	for _, entry := range engagements.crosshairEngagements() {
+		if included[entry.SteamID] {
+			analysis.Crosshair = append(analysis.Crosshair, entry)
+		}
+	}
+
+	// Duelos só entre participantes que entraram em players
+	for _, duel := range duels.duels() {
+		if included[duel.Players[0].SteamID] && included[duel.Players[1].SteamID] {
+			analysis.Duels = append(analysis.Duels, duel)
+		}
+	}
+
 	// Calcular MVP
 	var mvp *SimplePlayer
 	maxRating := 0.0
 	for _, player := range analysis.Players {
-		kd := 0.0
-		if player.Deaths > 0 {
-			kd = float64(player.Kills) / float64(player.Deaths)
-		} else if player.Kills > 0 {
-			kd = float64(player.Kills)
-		}
-		rating := kd * float64(player.ADR) / 100.0
+		rating := simpleRating(player.Kills, player.Deaths, player.ADR)
 		if rating > maxRating {
 			maxRating = rating
 			mvp = &player
@@ -703,36 +1124,116 @@ func main() {
 	}
 
 	// Se tiver targetPlayer, criar análise detalhada
-	if targetSteamID != 0 {
-		targetPlayer := findPlayerAnalysis(targetSteamID, playerMap, playerStats, officialRounds)
+	if opts.TargetSteamID != 0 {
+		targetPlayer := findPlayerAnalysis(opts.TargetSteamID, playerMap, stats, officialRounds)
 		if targetPlayer != nil {
 			analysis.TargetPlayer = targetPlayer
 		}
 	}
 
-	// Output JSON
-	jsonData, err := json.MarshalIndent(analysis, "", "  ")
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Erro ao serializar JSON: %v\n", err)
-		os.Exit(1)
+	logger.Info("Partida processada", "rounds", analysis.Metadata.Rounds, "events", len(analysis.Events),
+		"players", len(analysis.Players), "source", analysis.Metadata.Source,
+		"warmupRounds", analysis.Metadata.WarmupRounds, "duration", analysis.Metadata.Duration)
+	if diagnostics.Total > 0 {
+		logger.Warn("Avisos do parser durante a análise", "total", diagnostics.Total, "byKind", diagnostics.ByKind)
+	}
+	if analysis.Metadata.Truncated {
+		logger.Warn("Resultado parcial", "round", phases.current, "tick", p.GameState().IngameTick(),
+			"reason", analysis.Metadata.Error)
 	}
 
-	fmt.Print(string(jsonData))
-	fmt.Fprintf(os.Stderr, "[DEBUG] Partida processada: %d rounds, %d eventos, %d players\n",
-		officialRounds, len(analysis.Events), len(analysis.Players))
-	fmt.Fprintf(os.Stderr, "[DEBUG] Tipo: %s, Warmup: %d rounds\n", source, warmupCount)
+	return analysis, nil
 }
 
-func findPlayerAnalysis(steamID uint64, playerMap map[uint64]*SimplePlayer, playerStats map[uint64]*PlayerStats, rounds int) *PlayerAnalysis {
+// healthDamageTaken é o dano de vida limitado à vida que a vítima tinha (sem over-damage):
+// um AWP de 450 em quem tinha 20 de vida conta 20, como no placar do jogo
+func healthDamageTaken(e events.PlayerHurt) int {
+	return max(min(e.HealthDamageTaken, e.HealthDamage), 0)
+}
+
+// scoreboardDamageOf lê o dano do placar do jogo (m_iDamage). Player.TotalDamage usa
+// PropertyValueMust e entra em panic quando a propriedade não existe (demos antigas ou
+// danificadas); aqui a falta da propriedade só desliga a conferência.
+func scoreboardDamageOf(p *common.Player) (int, bool) {
+	if p.Entity == nil {
+		return 0, false
+	}
+	val, ok := p.Entity.PropertyValue("m_pActionTrackingServices.m_iDamage")
+	if !ok {
+		return 0, false
+	}
+	switch v := val.Any.(type) {
+	case int32:
+		return int(v), true
+	case int64:
+		return int(v), true
+	case uint32:
+		return int(v), true
+	case uint64:
+		return int(v), true
+	}
+	return 0, false
+}
+
+// newDemoParser cria o parser convertendo em erro o panic da primeira leitura
+// (stream descomprimido vazio ou ilegível)
+func newDemoParser(r io.Reader) (p demoinfocs.Parser, err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			p, err = nil, fmt.Errorf("%w: %v", errCorruptDemo, rec)
+		}
+	}()
+	return demoinfocs.NewParser(r), nil
+}
+
+// parseToEnd parseia frame a frame verificando o ctx entre frames, e converte
+// panics do parser (demos corrompidas) em erro
+func parseToEnd(ctx context.Context, p demoinfocs.Parser) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w: %v", errCorruptDemo, r)
+		}
+	}()
+
+	for {
+		if ctx.Err() != nil {
+			return fmt.Errorf("%w: %w", demoinfocs.ErrCancelled, context.Cause(ctx))
+		}
+		moreFrames, err := p.ParseNextFrame()
+		if err != nil {
+			return err
+		}
+		if !moreFrames {
+			return nil
+		}
+	}
+}
+
+// describeParseError traduz o erro de parse para a descrição em Metadata.Error
+func describeParseError(err error) string {
+	switch {
+	case errors.Is(err, errTimeout):
+		return "tempo limite da análise excedido antes do fim da demo"
+	case errors.Is(err, errInterrupted):
+		return "análise interrompida por sinal antes do fim da demo"
+	case errors.Is(err, demoinfocs.ErrCancelled):
+		return "análise cancelada antes do fim da demo"
+	case errors.Is(err, demoinfocs.ErrUnexpectedEndOfDemo):
+		return "demo truncada: o arquivo terminou antes do fim da partida"
+	case errors.Is(err, errCorruptDemo):
+		return fmt.Sprintf("demo corrompida: %v", err)
+	default:
+		return fmt.Sprintf("erro ao parsear demo: %v", err)
+	}
+}
+
+func findPlayerAnalysis(steamID uint64, playerMap map[uint64]*SimplePlayer, acc *statsAccumulator, rounds int) *PlayerAnalysis {
 	player, exists := playerMap[steamID]
 	if !exists {
 		return nil
 	}
 
-	stats, hasStats := playerStats[steamID]
-	if !hasStats {
-		stats = &PlayerStats{}
-	}
+	stats := acc.get(steamID)
 
 	hsRate := 0.0
 	if stats.Kills > 0 {
@@ -755,7 +1256,8 @@ func findPlayerAnalysis(steamID uint64, playerMap map[uint64]*SimplePlayer, play
 		Team:            player.Team,
 		Kills:           stats.Kills,
 		Deaths:          stats.Deaths,
-		Assists:         player.Assists,
+		Assists:         stats.Assists,
+		FlashAssists:    stats.FlashAssists,
 		HSKills:         stats.HSKills,
 		Damage:          stats.Damage,
 		ADR:             adr,
@@ -767,11 +1269,51 @@ func findPlayerAnalysis(steamID uint64, playerMap map[uint64]*SimplePlayer, play
 	}
 }
 
+// mapFromPath detecta o mapa pelo nome do arquivo
+func mapFromPath(demoPath string) string {
+	pathLower := strings.ToLower(demoPath)
+	if strings.Contains(pathLower, "de_mirage") {
+		return "de_mirage"
+	} else if strings.Contains(pathLower, "de_dust2") || strings.Contains(pathLower, "dust2") {
+		return "de_dust2"
+	} else if strings.Contains(pathLower, "de_inferno") {
+		return "de_inferno"
+	} else if strings.Contains(pathLower, "de_ancient") {
+		return "de_ancient"
+	} else if strings.Contains(pathLower, "de_vertigo") {
+		return "de_vertigo"
+	} else if strings.Contains(pathLower, "de_anubis") {
+		return "de_anubis"
+	} else if strings.Contains(pathLower, "de_overpass") {
+		return "de_overpass"
+	} else if strings.Contains(pathLower, "de_nuke") {
+		return "de_nuke"
+	}
+	return "unknown"
+}
+
+// simpleRating é o rating usado para o MVP: K/D multiplicado pelo ADR / 100
+func simpleRating(kills, deaths int, adr float64) float64 {
+	kd := 0.0
+	if deaths > 0 {
+		kd = float64(kills) / float64(deaths)
+	} else if kills > 0 {
+		kd = float64(kills)
+	}
+	return kd * adr / 100.0
+}
+
 func teamToString(t common.Team) string {
-	if t == common.TeamTerrorists {
+	switch t {
+	case common.TeamTerrorists:
 		return "T"
+	case common.TeamCounterTerrorists:
+		return "CT"
+	case common.TeamSpectators:
+		return "SPEC"
+	default:
+		return ""
 	}
-	return "CT"
 }
 
 func formatDuration(d time.Duration) string {
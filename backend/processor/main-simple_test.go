@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	demoinfocs "github.com/markus-wa/demoinfocs-golang/v5/pkg/demoinfocs"
+)
+
+// Demo CS2 mínima: cabeçalho PBDEMS2 e frames DEM_FileHeader (de_nuke), DEM_SyncTick e DEM_FileInfo
+const minimalDemoHex = "504244454d5332003f000000000000000100290a07504244454d533210b06d1a03737276220d536f7572636554562044656d6f2a0764655f6e756b6503000002000d0d0000e1441080840718b88207000000"
+
+func minimalDemo(t *testing.T) []byte {
+	t.Helper()
+	data, err := hex.DecodeString(minimalDemoHex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// truncatedDemo corta a demo mínima no meio: o cabeçalho é lido e o arquivo acaba no frame seguinte
+func truncatedDemo(t *testing.T) []byte {
+	return minimalDemo(t)[:60]
+}
+
+func writeFixture(t *testing.T, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestAnalyzeDemoTruncatedReturnsPartialResult(t *testing.T) {
+	path := writeFixture(t, "truncated.dem", truncatedDemo(t))
+
+	analysis, err := analyzeDemo(context.Background(), path, analyzeOptions{})
+	if err != nil {
+		t.Fatalf("demo truncada deve devolver resultado parcial, veio erro: %v", err)
+	}
+	if !analysis.Metadata.Truncated {
+		t.Error("metadata.truncated deveria ser true")
+	}
+	if want := describeParseError(demoinfocs.ErrUnexpectedEndOfDemo); analysis.Metadata.Error != want {
+		t.Errorf("metadata.error = %q, want %q", analysis.Metadata.Error, want)
+	}
+	if analysis.Players == nil || analysis.Events == nil || analysis.Rounds == nil {
+		t.Error("resultado parcial deve vir com as listas preenchidas (vazias), não nil")
+	}
+}
+
+func TestAnalyzeDemoComplete(t *testing.T) {
+	path := writeFixture(t, "minimal.dem", minimalDemo(t))
+
+	analysis, err := analyzeDemo(context.Background(), path, analyzeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if analysis.Metadata.Truncated || analysis.Metadata.Error != "" {
+		t.Errorf("demo completa marcada como parcial: %+v", analysis.Metadata)
+	}
+	// O nome do arquivo não tem o mapa: vem do cabeçalho
+	if analysis.Metadata.Map != "de_nuke" {
+		t.Errorf("metadata.map = %q, want de_nuke", analysis.Metadata.Map)
+	}
+}
+
+func TestAnalyzeDemoInvalidFile(t *testing.T) {
+	path := writeFixture(t, "garbage.dem", []byte("garbage"))
+
+	if _, err := analyzeDemo(context.Background(), path, analyzeOptions{}); err == nil {
+		t.Fatal("arquivo que não é demo deveria dar erro")
+	}
+}
+
+func TestParseToEndTruncated(t *testing.T) {
+	p := demoinfocs.NewParser(bytes.NewReader(truncatedDemo(t)))
+	defer p.Close()
+
+	err := parseToEnd(context.Background(), p)
+	if !errors.Is(err, demoinfocs.ErrUnexpectedEndOfDemo) {
+		t.Fatalf("err = %v, want ErrUnexpectedEndOfDemo", err)
+	}
+}
+
+func TestParseToEndCancelled(t *testing.T) {
+	p := demoinfocs.NewParser(bytes.NewReader(minimalDemo(t)))
+	defer p.Close()
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	cancel(errTimeout)
+	err := parseToEnd(ctx, p)
+	if !errors.Is(err, demoinfocs.ErrCancelled) || !errors.Is(err, errTimeout) {
+		t.Fatalf("err = %v, want ErrCancelled + errTimeout", err)
+	}
+}
+
+// panicParser simula o parser entrando em panic com dados inválidos
+type panicParser struct {
+	demoinfocs.Parser
+}
+
+func (panicParser) ParseNextFrame() (bool, error) {
+	panic("index out of range")
+}
+
+func TestParseToEndRecoversPanic(t *testing.T) {
+	err := parseToEnd(context.Background(), panicParser{})
+	if !errors.Is(err, errCorruptDemo) {
+		t.Fatalf("err = %v, want errCorruptDemo", err)
+	}
+}
+
+func TestParseUntilRecoversPanic(t *testing.T) {
+	err := parseUntil(panicParser{}, func() bool { return false })
+	if !errors.Is(err, errCorruptDemo) {
+		t.Fatalf("err = %v, want errCorruptDemo", err)
+	}
+}
+
+func TestReadDemoInfo(t *testing.T) {
+	info, err := readDemoInfo(writeFixture(t, "minimal.dem", minimalDemo(t)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Map != "de_nuke" {
+		t.Errorf("map = %q, want de_nuke", info.Map)
+	}
+
+	if _, err := readDemoInfo(writeFixture(t, "truncated.dem", truncatedDemo(t))); err == nil {
+		t.Error("demo truncada antes do signon deveria dar erro")
+	}
+}
+
+func TestDescribeParseError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{demoinfocs.ErrUnexpectedEndOfDemo, "demo truncada"},
+		{errors.Join(errCorruptDemo, errors.New("x")), "demo corrompida"},
+		{errors.Join(demoinfocs.ErrCancelled, errTimeout), "tempo limite"},
+		{errors.Join(demoinfocs.ErrCancelled, errInterrupted), "interrompida por sinal"},
+		{demoinfocs.ErrCancelled, "cancelada"},
+		{errors.New("outro"), "erro ao parsear demo"},
+	}
+	for _, tt := range tests {
+		if got := describeParseError(tt.err); !strings.Contains(got, tt.want) {
+			t.Errorf("describeParseError(%v) = %q, want contendo %q", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	common "github.com/markus-wa/demoinfocs-golang/v5/pkg/demoinfocs/common"
+)
+
+// Papéis de participante em SimplePlayer.Role
+const (
+	rolePlayer    = "player"
+	roleCoach     = "coach"
+	roleSpectator = "spectator"
+	roleBot       = "bot"
+)
+
+// Prioridade ao combinar papéis vistos em momentos diferentes (ex.: jogador que
+// desconectou e terminou como espectador continua jogador)
+var rolePriority = map[string]int{
+	roleSpectator: 0,
+	rolePlayer:    1,
+	roleCoach:     2,
+	roleBot:       3,
+}
+
+// participantID identifica o participante nos mapas de stats. Bots não têm SteamID em
+// demos CS2: usam o índice da entidade do controller, que nunca colide com um SteamID64.
+func participantID(p *common.Player) uint64 {
+	if p.SteamID64 == 0 && p.IsBot {
+		return uint64(p.EntityID)
+	}
+	return p.SteamID64
+}
+
+// participantRole classifica o participante no momento atual
+func participantRole(p *common.Player) string {
+	switch {
+	case p.IsBot:
+		return roleBot
+	case isCoach(p):
+		return roleCoach
+	case p.Team == common.TeamTerrorists || p.Team == common.TeamCounterTerrorists:
+		return rolePlayer
+	default:
+		return roleSpectator
+	}
+}
+
+// mergeRole mantém o papel de maior prioridade
+func mergeRole(current, seen string) string {
+	if current == "" || rolePriority[seen] > rolePriority[current] {
+		return seen
+	}
+	return current
+}
+
+// isCoach lê m_iCoachingTeam do controller: diferente de 0 quando o participante é coach
+func isCoach(p *common.Player) bool {
+	if p.Entity == nil {
+		return false
+	}
+	val, exists := p.Entity.PropertyValue("m_iCoachingTeam")
+	if !exists {
+		return false
+	}
+	switch v := val.Any.(type) {
+	case int32:
+		return v != 0
+	case uint32:
+		return v != 0
+	case uint64:
+		return v != 0
+	case int64:
+		return v != 0
+	}
+	return false
+}
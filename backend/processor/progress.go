@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// Intervalo mínimo entre dois registros de progresso
+const progressInterval = 500 * time.Millisecond
+
+// ProgressRecord é emitido periodicamente durante o parse, um JSON por linha
+type ProgressRecord struct {
+	Type    string  `json:"type"`    // Sempre "progress"
+	Percent float64 `json:"percent"` // 0-100
+	Tick    int     `json:"tick"`
+	Round   int     `json:"round"`
+	Elapsed float64 `json:"elapsed"` // Segundos desde o início do parse
+	Done    bool    `json:"done,omitempty"`
+}
+
+// progressTracker decide quando emitir e calcula o percentual.
+// Em demos CS2 o total de frames só chega no CDemoFileInfo (fim da demo), então o
+// Progress() do parser fica em 0: nesse caso usamos o total de ticks lido pelo offset.
+type progressTracker struct {
+	onProgress    func(ProgressRecord)
+	playbackTicks int
+	start         time.Time
+	last          time.Time
+}
+
+func newProgressTracker(onProgress func(ProgressRecord), playbackTicks int) *progressTracker {
+	now := time.Now()
+	return &progressTracker{onProgress: onProgress, playbackTicks: playbackTicks, start: now, last: now}
+}
+
+// update emite um registro se o intervalo já passou
+func (t *progressTracker) update(parserProgress float32, tick, round int) {
+	now := time.Now()
+	if now.Sub(t.last) < progressInterval {
+		return
+	}
+	t.last = now
+	t.onProgress(t.record(parserProgress, tick, round, false))
+}
+
+// done emite o registro final (100% se o parse chegou ao fim)
+func (t *progressTracker) done(parserProgress float32, tick, round int, complete bool) {
+	record := t.record(parserProgress, tick, round, true)
+	if complete {
+		record.Percent = 100
+	}
+	t.onProgress(record)
+}
+
+func (t *progressTracker) record(parserProgress float32, tick, round int, done bool) ProgressRecord {
+	percent := float64(parserProgress) * 100
+	if percent <= 0 && t.playbackTicks > 0 {
+		percent = float64(tick) / float64(t.playbackTicks) * 100
+	}
+	percent = min(max(percent, 0), 100)
+
+	return ProgressRecord{
+		Type:    "progress",
+		Percent: float64(int(percent*10)) / 10,
+		Tick:    tick,
+		Round:   round,
+		Elapsed: float64(int(time.Since(t.start).Seconds()*10)) / 10,
+		Done:    done,
+	}
+}
+
+// demoPlaybackTicks lê o total de ticks do CDemoFileInfo e volta o arquivo ao início.
+// Retorna 0 quando a entrada não permite Seek (comprimida ou stdin).
+func demoPlaybackTicks(r io.Reader) int {
+	rs, ok := r.(io.ReadSeeker)
+	if !ok {
+		return 0
+	}
+	fileInfo, _ := readFileInfo(rs)
+	if _, err := rs.Seek(0, io.SeekStart); err != nil || fileInfo == nil {
+		return 0
+	}
+	return int(fileInfo.GetPlaybackTicks())
+}
+
+// jsonLinesProgress escreve cada registro como uma linha JSON no writer
+func jsonLinesProgress(w io.Writer) func(ProgressRecord) {
+	var mu sync.Mutex
+	enc := json.NewEncoder(w)
+	return func(record ProgressRecord) {
+		mu.Lock()
+		defer mu.Unlock()
+		enc.Encode(record)
+	}
+}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"sort"
+
+	common "github.com/markus-wa/demoinfocs-golang/v5/pkg/demoinfocs/common"
+	events "github.com/markus-wa/demoinfocs-golang/v5/pkg/demoinfocs/events"
+)
+
+// RoundInfo marca em que parte da partida um round oficial foi jogado
+type RoundInfo struct {
+	Round    int    `json:"round"`
+	Half     int    `json:"half"`               // 1 ou 2 dentro do período
+	Overtime int    `json:"overtime,omitempty"` // 0 = tempo regulamentar, 1.. = prorrogação
+	SideSwap bool   `json:"sideSwap,omitempty"` // Primeiro round depois de uma troca de lado
+	Winner   string `json:"winner,omitempty"`   // Lado vencedor ("T" ou "CT")
+
+	swaps int // Trocas de lado até este round (inclusive)
+}
+
+// PeriodScore é o placar de um período (tempo regulamentar ou uma prorrogação).
+// Os lados seguem ScoreT/ScoreCT da partida: o time que terminou a partida de T/CT.
+type PeriodScore struct {
+	Overtime int `json:"overtime"`
+	ScoreT   int `json:"scoreT"`
+	ScoreCT  int `json:"scoreCT"`
+}
+
+// roundTracker acompanha metades e prorrogações pelas regras do jogo
+// (TeamSideSwitch e m_nOvertimePlaying), sem supor MR12/MR3
+type roundTracker struct {
+	rounds      map[int]*RoundInfo
+	swaps       int
+	lastSwaps   int
+	periodOT    int
+	periodSwaps int
+	started     bool // Já marcou o primeiro round oficial desde o último restart
+}
+
+func newRoundTracker() *roundTracker {
+	return &roundTracker{rounds: make(map[int]*RoundInfo)}
+}
+
+// sideSwitched registra uma troca de lado (halftime ou início de prorrogação)
+func (t *roundTracker) sideSwitched() {
+	t.swaps++
+}
+
+// restarted zera a referência das metades: trocas de lado antes do primeiro round oficial
+// (warmup, escolha de lado depois da faca, restart) não contam como halftime
+func (t *roundTracker) restarted() {
+	t.started = false
+}
+
+// tag marca o round com o período atual; chamado uma vez por round, depois da troca de lado
+func (t *roundTracker) tag(round, overtime int) *RoundInfo {
+	if info, exists := t.rounds[round]; exists {
+		return info
+	}
+
+	// Primeiro round oficial: as trocas até aqui são a referência da 1ª metade, a mesma
+	// que buildTeams usa (swaps do primeiro round)
+	if !t.started {
+		t.started = true
+		t.periodOT = overtime
+		t.periodSwaps = t.swaps
+		t.lastSwaps = t.swaps
+	}
+	if overtime != t.periodOT {
+		t.periodOT = overtime
+		t.periodSwaps = t.swaps
+	}
+
+	info := &RoundInfo{
+		Round:    round,
+		Half:     min(1+t.swaps-t.periodSwaps, 2),
+		Overtime: overtime,
+		SideSwap: t.swaps != t.lastSwaps,
+		swaps:    t.swaps,
+	}
+	t.lastSwaps = t.swaps
+	t.rounds[round] = info
+	return info
+}
+
+// official devolve os rounds marcados, em ordem, sem os que skip descarta (warmup/faca)
+func (t *roundTracker) official(skip func(round int) bool) []RoundInfo {
+	rounds := []RoundInfo{}
+	for round, info := range t.rounds {
+		if !skip(round) {
+			rounds = append(rounds, *info)
+		}
+	}
+	sort.Slice(rounds, func(i, j int) bool { return rounds[i].Round < rounds[j].Round })
+	return rounds
+}
+
+// periodScores soma os rounds vencidos por período. O vencedor de cada round é convertido
+// para o lado em que o time terminou a partida (trocas de lado posteriores invertem o lado).
+func (t *roundTracker) periodScores(rounds []RoundInfo) (PeriodScore, []PeriodScore) {
+	regulation := PeriodScore{}
+	overtimes := []PeriodScore{}
+
+	for _, info := range rounds {
+		if info.Winner == "" {
+			continue
+		}
+		winner := info.Winner
+		if (t.swaps-info.swaps)%2 == 1 {
+			winner = oppositeSide(winner)
+		}
+
+		score := &regulation
+		if info.Overtime > 0 {
+			for len(overtimes) < info.Overtime {
+				overtimes = append(overtimes, PeriodScore{Overtime: len(overtimes) + 1})
+			}
+			score = &overtimes[info.Overtime-1]
+		}
+		if winner == "T" {
+			score.ScoreT++
+		} else {
+			score.ScoreCT++
+		}
+	}
+	return regulation, overtimes
+}
+
+func oppositeSide(side string) string {
+	if side == "T" {
+		return "CT"
+	}
+	return "T"
+}
+
+// restartDiscards decide quais rounds um restart da partida descarta: todos até o atual que
+// ainda não são aquecimento, exceto rounds de faca (decidem os lados). Round já iniciado no
+// freezetime é o primeiro depois do restart e fica de fora.
+func restartDiscards(currentRound int, inFreezetime bool, warmup, knife map[int]bool) (lastPreMatch int, discarded []int) {
+	lastPreMatch = currentRound
+	if inFreezetime {
+		lastPreMatch--
+	}
+	for r := 1; r <= lastPreMatch; r++ {
+		if !knife[r] && !warmup[r] {
+			discarded = append(discarded, r)
+		}
+	}
+	return lastPreMatch, discarded
+}
+
+// gameRules é a parte do GameState que decide as fases da partida
+type gameRules interface {
+	IsWarmupPeriod() bool
+	IsMatchStarted() bool
+	IsFreezetimePeriod() bool
+	OvertimeCount() int
+}
+
+// isWarmupState diz se as regras do jogo estão em aquecimento ou antes do início da partida
+func isWarmupState(rules gameRules) bool {
+	return rules.IsWarmupPeriod() || !rules.IsMatchStarted()
+}
+
+// restartSignal diz se o evento marca o início da partida: begin_new_match ou, nas demos
+// que não o têm, m_bHasMatchStarted virando true ou o fim do warmup
+func restartSignal(e any) (signal string, ok bool) {
+	switch e := e.(type) {
+	case events.MatchStart:
+		return "begin_new_match", true
+	case events.MatchStartedChanged:
+		return "match_started", e.NewIsStarted && !e.OldIsStarted
+	case events.IsWarmupPeriodChanged:
+		return "warmup_end", e.OldIsWarmupPeriod && !e.NewIsWarmupPeriod
+	}
+	return "", false
+}
+
+// matchPhases acompanha a fase de cada round (aquecimento, faca, oficial) e os restarts
+// na ordem em que os eventos chegam; os handlers do analyzeDemo só repassam os eventos
+type matchPhases struct {
+	current    int
+	inProgress bool
+	preMatch   int // Rounds fora do aquecimento descartados por restart (GC)
+	warmup     map[int]bool
+	knife      map[int]bool
+	rounds     *roundTracker
+}
+
+func newMatchPhases() *matchPhases {
+	return &matchPhases{
+		warmup: make(map[int]bool),
+		knife:  make(map[int]bool),
+		rounds: newRoundTracker(),
+	}
+}
+
+// roundStart abre o próximo round; aquecimento pelas regras do jogo (rounds antes de um
+// restart são marcados depois, no restart)
+func (m *matchPhases) roundStart(rules gameRules) (warmup bool) {
+	m.current++
+	m.inProgress = true
+	if isWarmupState(rules) {
+		m.warmup[m.current] = true
+	}
+	return m.warmup[m.current]
+}
+
+// restart descarta o que foi jogado antes do início da partida; um segundo sinal do
+// mesmo restart não descarta nada
+func (m *matchPhases) restart(rules gameRules) (lastPreMatch int, discarded []int) {
+	lastPreMatch, discarded = restartDiscards(m.current, m.inProgress && rules.IsFreezetimePeriod(), m.warmup, m.knife)
+	if len(discarded) == 0 {
+		return lastPreMatch, nil
+	}
+	for _, r := range discarded {
+		m.warmup[r] = true
+	}
+	m.preMatch += len(discarded)
+	m.rounds.restarted()
+	return lastPreMatch, discarded
+}
+
+// freezetimeEnd classifica o round pelo loadout: faca ou oficial (marcado no roundTracker).
+// Devolve se o round é oficial.
+func (m *matchPhases) freezetimeEnd(rules gameRules, knifeOnly bool) bool {
+	if m.warmup[m.current] || isWarmupState(rules) {
+		return false
+	}
+	if knifeOnly {
+		m.knife[m.current] = true
+		return false
+	}
+	m.rounds.tag(m.current, rules.OvertimeCount())
+	return true
+}
+
+func (m *matchPhases) sideSwitched() {
+	m.rounds.sideSwitched()
+}
+
+// roundEnd fecha o round e grava o vencedor; nil para aquecimento e faca. Demo que começa
+// no meio do round não tem o fim do freezetime: o round é marcado aqui.
+func (m *matchPhases) roundEnd(rules gameRules, winner common.Team) *RoundInfo {
+	m.inProgress = false
+	if m.warmup[m.current] || m.knife[m.current] {
+		return nil
+	}
+	info := m.rounds.tag(m.current, rules.OvertimeCount())
+	if winner == common.TeamTerrorists || winner == common.TeamCounterTerrorists {
+		info.Winner = teamToString(winner)
+	}
+	return info
+}
+
+// skipped diz se o round não conta (aquecimento, antes do restart ou faca)
+func (m *matchPhases) skipped(round int) bool {
+	return m.warmup[round] || m.knife[round]
+}
+
+// firstOfficialRound é o primeiro round depois do aquecimento e dos restarts (0 = nenhum)
+func (m *matchPhases) firstOfficialRound() int {
+	for r := 1; r <= m.current; r++ {
+		if !m.skipped(r) {
+			return r
+		}
+	}
+	return 0
+}
+
+// official são os rounds que contam, em ordem, com metade e prorrogação
+func (m *matchPhases) official() []RoundInfo {
+	return m.rounds.official(m.skipped)
+}
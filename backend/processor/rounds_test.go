@@ -0,0 +1,250 @@
+package main
+
+import (
+	"slices"
+	"testing"
+
+	common "github.com/markus-wa/demoinfocs-golang/v5/pkg/demoinfocs/common"
+	events "github.com/markus-wa/demoinfocs-golang/v5/pkg/demoinfocs/events"
+)
+
+// Valve MM: warmup (round 1 marcado pelo estado do jogo) e begin_new_match no freezetime do round 2
+func TestRestartDiscardsValveMM(t *testing.T) {
+	warmup := map[int]bool{1: true}
+	knife := map[int]bool{}
+
+	lastPreMatch, discarded := restartDiscards(2, true, warmup, knife)
+	if lastPreMatch != 1 || len(discarded) != 0 {
+		t.Fatalf("got lastPreMatch=%d discarded=%v, want 1 e nada", lastPreMatch, discarded)
+	}
+}
+
+// GC: warmup, faca, um round "não live" e o restart (sem begin_new_match, só m_bHasMatchStarted)
+// no freezetime do round 4. O round 3 é descartado e a faca continua faca.
+func TestRestartDiscardsGC(t *testing.T) {
+	warmup := map[int]bool{1: true}
+	knife := map[int]bool{2: true}
+
+	lastPreMatch, discarded := restartDiscards(4, true, warmup, knife)
+	if lastPreMatch != 3 || !slices.Equal(discarded, []int{3}) {
+		t.Fatalf("got lastPreMatch=%d discarded=%v, want 3 e [3]", lastPreMatch, discarded)
+	}
+
+	// O mesmo restart sinalizado de novo (warmup_end/match_started) não descarta mais nada
+	for _, r := range discarded {
+		warmup[r] = true
+	}
+	if _, again := restartDiscards(4, true, warmup, knife); len(again) != 0 {
+		t.Fatalf("segundo sinal descartou %v", again)
+	}
+}
+
+// Restart depois do fim do round (fora do freezetime) descarta também o round atual
+func TestRestartDiscardsAfterRoundEnd(t *testing.T) {
+	_, discarded := restartDiscards(3, false, map[int]bool{}, map[int]bool{})
+	if !slices.Equal(discarded, []int{1, 2, 3}) {
+		t.Fatalf("discarded = %v, want [1 2 3]", discarded)
+	}
+}
+
+// Metades e prorrogação pelas trocas de lado e placar por período no lado final
+func TestRoundTrackerPeriods(t *testing.T) {
+	tracker := newRoundTracker()
+	for round := 1; round <= 30; round++ {
+		overtime := 0
+		if round > 24 {
+			overtime = 1
+		}
+		// Trocas antes do round 13 (halftime), 25 (início da prorrogação) e 28 (metade da OT)
+		if round == 13 || round == 25 || round == 28 {
+			tracker.sideSwitched()
+		}
+		info := tracker.tag(round, overtime)
+		info.Winner = "CT"
+	}
+
+	rounds := tracker.official(func(int) bool { return false })
+	if got := rounds[12]; got.Half != 2 || !got.SideSwap || got.Overtime != 0 {
+		t.Errorf("round 13: %+v", got)
+	}
+	if got := rounds[24]; got.Half != 1 || got.Overtime != 1 {
+		t.Errorf("round 25: %+v", got)
+	}
+	if got := rounds[27]; got.Half != 2 || got.Overtime != 1 {
+		t.Errorf("round 28: %+v", got)
+	}
+
+	regulation, overtimes := tracker.periodScores(rounds)
+	if regulation.ScoreT+regulation.ScoreCT != 24 || regulation.ScoreT != 12 {
+		t.Errorf("regulation = %+v, want 12 x 12", regulation)
+	}
+	if len(overtimes) != 1 || overtimes[0].ScoreT != 3 || overtimes[0].ScoreCT != 3 {
+		t.Errorf("overtimes = %+v", overtimes)
+	}
+}
+
+// Troca de lado antes do primeiro round oficial (lado escolhido depois da faca, warmup ou
+// restart) não faz a 1ª metade virar metade 2
+func TestRoundTrackerSwitchBeforeFirstOfficialRound(t *testing.T) {
+	tracker := newRoundTracker()
+	// Faca (round 1 não é marcado) e o vencedor escolhe trocar
+	tracker.sideSwitched()
+	for round := 2; round <= 14; round++ {
+		if round == 14 {
+			tracker.sideSwitched()
+		}
+		tracker.tag(round, 0)
+	}
+	if got := tracker.rounds[2]; got.Half != 1 || got.SideSwap {
+		t.Errorf("primeiro round oficial: %+v", got)
+	}
+	if got := tracker.rounds[14]; got.Half != 2 || !got.SideSwap {
+		t.Errorf("round depois do halftime: %+v", got)
+	}
+
+	// GC: rounds marcados antes do restart, troca de lado no restart e a partida de novo
+	tracker = newRoundTracker()
+	tracker.tag(1, 0)
+	tracker.tag(2, 0)
+	tracker.sideSwitched()
+	tracker.restarted()
+	if got := tracker.tag(3, 0); got.Half != 1 || got.SideSwap {
+		t.Errorf("primeiro round depois do restart: %+v", got)
+	}
+}
+
+// fakeRules é o estado das regras do jogo no momento de cada evento gravado
+type fakeRules struct {
+	warmup, started, freezetime bool
+	overtime                    int
+}
+
+func (r fakeRules) IsWarmupPeriod() bool     { return r.warmup }
+func (r fakeRules) IsMatchStarted() bool     { return r.started }
+func (r fakeRules) IsFreezetimePeriod() bool { return r.freezetime }
+func (r fakeRules) OvertimeCount() int       { return r.overtime }
+
+// recordedEvent é um evento da demo com as regras do jogo naquele tick. O loadout de faca
+// vem junto do RoundFreezetimeEnd, como o handler lê dos jogadores.
+type recordedEvent struct {
+	event any
+	rules fakeRules
+	knife bool
+}
+
+// replayPhases repassa os eventos ao matchPhases como os handlers do analyzeDemo fazem
+func replayPhases(recorded []recordedEvent) *matchPhases {
+	phases := newMatchPhases()
+	for _, r := range recorded {
+		switch e := r.event.(type) {
+		case events.RoundStart:
+			phases.roundStart(r.rules)
+		case events.RoundFreezetimeEnd:
+			phases.freezetimeEnd(r.rules, r.knife)
+		case events.TeamSideSwitch:
+			phases.sideSwitched()
+		case events.RoundEnd:
+			phases.roundEnd(r.rules, e.Winner)
+		default:
+			if _, ok := restartSignal(e); ok {
+				phases.restart(r.rules)
+			}
+		}
+	}
+	return phases
+}
+
+// playedRound grava um round completo com as regras dadas
+func playedRound(rules fakeRules, knife bool) []recordedEvent {
+	freeze := rules
+	freeze.freezetime = true
+	return []recordedEvent{
+		{event: events.RoundStart{}, rules: freeze},
+		{event: events.RoundFreezetimeEnd{}, rules: rules, knife: knife},
+		{event: events.RoundEnd{Winner: common.TeamTerrorists}, rules: rules},
+	}
+}
+
+// Valve MM: round de warmup, fim do warmup e m_bHasMatchStarted entre os rounds e
+// begin_new_match no freezetime do primeiro round oficial; halftime depois de 12 rounds
+func TestMatchPhasesValveMM(t *testing.T) {
+	live := fakeRules{started: true}
+	liveFreeze := fakeRules{started: true, freezetime: true}
+
+	recorded := playedRound(fakeRules{warmup: true}, false)
+	recorded = append(recorded,
+		recordedEvent{event: events.IsWarmupPeriodChanged{OldIsWarmupPeriod: true}, rules: live},
+		recordedEvent{event: events.MatchStartedChanged{NewIsStarted: true}, rules: live},
+		recordedEvent{event: events.RoundStart{}, rules: liveFreeze},
+		recordedEvent{event: events.MatchStart{}, rules: liveFreeze},
+		recordedEvent{event: events.RoundFreezetimeEnd{}, rules: live},
+		recordedEvent{event: events.RoundEnd{Winner: common.TeamTerrorists}, rules: live},
+	)
+	for range 11 {
+		recorded = append(recorded, playedRound(live, false)...)
+	}
+	recorded = append(recorded, recordedEvent{event: events.TeamSideSwitch{}, rules: live})
+	recorded = append(recorded, playedRound(live, false)...)
+
+	phases := replayPhases(recorded)
+	assertPhases(t, phases, 2, 0, 13)
+}
+
+// GC: warmup, faca, um round "não live", a escolha de lado (TeamSideSwitch) e o
+// begin_new_match no freezetime do round 4, sinalizado de novo por m_bHasMatchStarted
+func TestMatchPhasesGC(t *testing.T) {
+	live := fakeRules{started: true}
+	liveFreeze := fakeRules{started: true, freezetime: true}
+
+	recorded := playedRound(fakeRules{warmup: true}, false)
+	recorded = append(recorded,
+		recordedEvent{event: events.IsWarmupPeriodChanged{OldIsWarmupPeriod: true}, rules: live},
+		recordedEvent{event: events.MatchStartedChanged{NewIsStarted: true}, rules: live},
+	)
+	recorded = append(recorded, playedRound(live, true)...)
+	recorded = append(recorded, playedRound(live, false)...)
+	recorded = append(recorded, recordedEvent{event: events.TeamSideSwitch{}, rules: live})
+	recorded = append(recorded,
+		recordedEvent{event: events.RoundStart{}, rules: liveFreeze},
+		recordedEvent{event: events.MatchStart{}, rules: liveFreeze},
+		recordedEvent{event: events.MatchStartedChanged{NewIsStarted: true}, rules: liveFreeze},
+		recordedEvent{event: events.RoundFreezetimeEnd{}, rules: live},
+		recordedEvent{event: events.RoundEnd{Winner: common.TeamTerrorists}, rules: live},
+	)
+	for range 11 {
+		recorded = append(recorded, playedRound(live, false)...)
+	}
+	recorded = append(recorded, recordedEvent{event: events.TeamSideSwitch{}, rules: live})
+	recorded = append(recorded, playedRound(live, false)...)
+
+	phases := replayPhases(recorded)
+	assertPhases(t, phases, 4, 1, 13)
+	if !phases.knife[2] {
+		t.Errorf("round 2 não ficou como faca")
+	}
+}
+
+// assertPhases confere o primeiro round oficial, os rounds descartados pelo restart e as
+// metades: 12 rounds na 1ª (sem troca de lado contada) e o último na 2ª
+func assertPhases(t *testing.T, phases *matchPhases, first, preMatch, official int) {
+	t.Helper()
+	if got := phases.firstOfficialRound(); got != first {
+		t.Fatalf("firstOfficialRound = %d, want %d", got, first)
+	}
+	if phases.preMatch != preMatch {
+		t.Errorf("preMatch = %d, want %d", phases.preMatch, preMatch)
+	}
+	rounds := phases.official()
+	if len(rounds) != official {
+		t.Fatalf("%d rounds oficiais, want %d", len(rounds), official)
+	}
+	for i, info := range rounds {
+		wantHalf, wantSwap := 1, false
+		if i == 12 {
+			wantHalf, wantSwap = 2, true
+		}
+		if info.Round != first+i || info.Half != wantHalf || info.SideSwap != wantSwap {
+			t.Errorf("rounds[%d] = %+v, want round %d half %d sideSwap %v", i, info, first+i, wantHalf, wantSwap)
+		}
+	}
+}
@@ -0,0 +1,577 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Status dos jobs do modo serve
+const (
+	jobQueued    = "queued"
+	jobRunning   = "running"
+	jobCompleted = "completed"
+	jobFailed    = "failed"
+	jobCancelled = "cancelled"
+)
+
+// Mesmo limite de upload do backend Node
+const maxUploadSize = 450 * 1024 * 1024
+
+// JobStatus é o que GET /jobs/{id} devolve
+type JobStatus struct {
+	ID         string          `json:"id"`
+	Status     string          `json:"status"`
+	DemoPath   string          `json:"demoPath"`
+	SteamID    uint64          `json:"steamID,omitempty"`
+	Partial    bool            `json:"partial,omitempty"`
+	Error      string          `json:"error,omitempty"`
+	Progress   *ProgressRecord `json:"progress,omitempty"` // Último progresso do parse
+	CreatedAt  time.Time       `json:"createdAt"`
+	StartedAt  *time.Time      `json:"startedAt,omitempty"`
+	FinishedAt *time.Time      `json:"finishedAt,omitempty"`
+}
+
+// serveJob é uma análise submetida ao modo serve
+type serveJob struct {
+	JobStatus
+
+	uploaded bool // Demo enviada por upload: apagada junto com o job
+	ctx      context.Context
+	cancel   context.CancelFunc
+	analysis *SimpleAnalysis
+
+	framesMu sync.Mutex // Serializa a extração; só o sucesso fica em cache
+	frames   *roundFrames
+}
+
+// roundFrames guarda a saída do extract-frames já separada por round
+type roundFrames struct {
+	Map     string
+	ByRound map[int][]json.RawMessage
+}
+
+type processorServer struct {
+	mu        sync.Mutex
+	jobs      map[string]*serveJob
+	queue     chan *serveJob
+	uploadDir string
+	timeout   time.Duration // Tempo máximo por análise (0 = sem limite)
+
+	retention   time.Duration // Tempo que um job finalizado fica disponível (0 = sem limite)
+	maxFinished int           // Máximo de jobs finalizados guardados (0 = sem limite)
+}
+
+func runServe(args []string) {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := fs.String("addr", "127.0.0.1:8090", "endereço HTTP (use localhost: não há autenticação)")
+	workers := fs.Int("workers", defaultServeWorkers(), "análises executadas em paralelo")
+	queueSize := fs.Int("queue", 16, "máximo de jobs aguardando na fila")
+	uploadDir := fs.String("upload-dir", filepath.Join(os.TempDir(), "cs2-demo-processor"), "diretório para demos enviadas por upload")
+	timeout := fs.Duration("timeout", 0, "tempo máximo por análise; ao estourar o job termina com resultado parcial")
+	retention := fs.Duration("retention", time.Hour, "tempo que um job finalizado fica disponível antes de ser removido (0 = sem limite)")
+	maxFinished := fs.Int("max-finished", 100, "máximo de jobs finalizados guardados; os mais antigos são removidos (0 = sem limite)")
+	logOpts := addLogFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Uso: %s serve [-addr host:porta] [-workers n] [-queue n] [-upload-dir dir] [-timeout d] [-retention d] [-max-finished n]\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	logOpts.setup()
+
+	if err := os.MkdirAll(*uploadDir, 0o755); err != nil {
+		slog.Error("Erro ao criar diretório de upload", "dir", *uploadDir, "err", err)
+		os.Exit(1)
+	}
+
+	srv := &processorServer{
+		jobs:        make(map[string]*serveJob),
+		queue:       make(chan *serveJob, max(*queueSize, 1)),
+		uploadDir:   *uploadDir,
+		timeout:     *timeout,
+		retention:   *retention,
+		maxFinished: *maxFinished,
+	}
+	for w := 0; w < max(*workers, 1); w++ {
+		go srv.worker()
+	}
+	go srv.janitor()
+
+	slog.Info("Servidor escutando", "addr", "http://"+*addr, "workers", max(*workers, 1), "queue", cap(srv.queue))
+	if err := http.ListenAndServe(*addr, srv.routes()); err != nil {
+		slog.Error("Erro no servidor HTTP", "err", err)
+		os.Exit(1)
+	}
+}
+
+func defaultServeWorkers() int {
+	return min(runtime.NumCPU(), 2)
+}
+
+func (s *processorServer) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /health", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]any{"status": "ok", "queued": len(s.queue)})
+	})
+	mux.HandleFunc("POST /jobs", s.handleSubmit)
+	mux.HandleFunc("GET /jobs/{id}", s.handleStatus)
+	mux.HandleFunc("GET /jobs/{id}/analysis", s.handleAnalysis)
+	mux.HandleFunc("GET /jobs/{id}/frames", s.handleFrames)
+	mux.HandleFunc("POST /jobs/{id}/cancel", s.handleCancel)
+	mux.HandleFunc("DELETE /jobs/{id}", s.handleDelete)
+	return mux
+}
+
+// handleSubmit aceita JSON {"path": "...", "steamId": "..."} com o caminho de uma demo local
+// ou multipart com o arquivo no campo "demo" (mesmo campo do upload do backend Node).
+func (s *processorServer) handleSubmit(w http.ResponseWriter, r *http.Request) {
+	job := &serveJob{JobStatus: JobStatus{CreatedAt: time.Now()}}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		r.Body = http.MaxBytesReader(w, r.Body, maxUploadSize)
+		file, header, err := r.FormFile("demo")
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "upload sem o campo \"demo\": "+err.Error())
+			return
+		}
+		defer file.Close()
+
+		if !isDemoFile(header.Filename) {
+			writeError(w, http.StatusBadRequest, "apenas arquivos .dem (ou .dem.gz/.dem.bz2/.dem.zst) são permitidos")
+			return
+		}
+
+		demoPath, err := s.saveUpload(file, header.Filename)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "erro ao salvar upload: "+err.Error())
+			return
+		}
+		job.DemoPath = demoPath
+		job.uploaded = true
+		job.SteamID = parseSteamID(r.FormValue("steamId"))
+	} else {
+		var req struct {
+			Path    string `json:"path"`
+			SteamID string `json:"steamId"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Path == "" {
+			writeError(w, http.StatusBadRequest, "corpo deve ser JSON {\"path\": \"...\"} ou multipart com o campo \"demo\"")
+			return
+		}
+		if _, err := os.Stat(req.Path); err != nil {
+			writeError(w, http.StatusBadRequest, "demo não encontrada: "+err.Error())
+			return
+		}
+		job.DemoPath = req.Path
+		job.SteamID = parseSteamID(req.SteamID)
+	}
+
+	job.ID = newJobID()
+	job.Status = jobQueued
+	job.ctx, job.cancel = context.WithCancel(context.Background())
+
+	s.mu.Lock()
+	select {
+	case s.queue <- job:
+		s.jobs[job.ID] = job
+		s.mu.Unlock()
+	default:
+		s.mu.Unlock()
+		job.cancel()
+		if job.uploaded {
+			os.Remove(job.DemoPath)
+		}
+		writeError(w, http.StatusServiceUnavailable, "fila de análise cheia, tente novamente mais tarde")
+		return
+	}
+
+	slog.Info("Job na fila", "job", job.ID, "demo", job.DemoPath)
+	writeJSON(w, http.StatusAccepted, s.snapshot(job))
+}
+
+func (s *processorServer) handleStatus(w http.ResponseWriter, r *http.Request) {
+	job := s.lookup(w, r)
+	if job == nil {
+		return
+	}
+	writeJSON(w, http.StatusOK, s.snapshot(job))
+}
+
+func (s *processorServer) handleAnalysis(w http.ResponseWriter, r *http.Request) {
+	job := s.lookup(w, r)
+	if job == nil {
+		return
+	}
+
+	s.mu.Lock()
+	status, analysis := job.Status, job.analysis
+	s.mu.Unlock()
+
+	if analysis == nil {
+		writeError(w, http.StatusConflict, "análise indisponível (status: "+status+")")
+		return
+	}
+	writeJSON(w, http.StatusOK, analysis)
+}
+
+// handleFrames devolve os frames do replay 2D de um round (?round=N).
+// Os frames são extraídos uma única vez por job com o binário extract-frames.
+func (s *processorServer) handleFrames(w http.ResponseWriter, r *http.Request) {
+	job := s.lookup(w, r)
+	if job == nil {
+		return
+	}
+
+	round, err := strconv.Atoi(r.URL.Query().Get("round"))
+	if err != nil || round < 0 {
+		writeError(w, http.StatusBadRequest, "parâmetro round inválido")
+		return
+	}
+
+	s.mu.Lock()
+	status := job.Status
+	s.mu.Unlock()
+	if status != jobCompleted {
+		writeError(w, http.StatusConflict, "frames disponíveis só após a análise (status: "+status+")")
+		return
+	}
+
+	// Falhas não ficam em cache: a próxima requisição tenta extrair de novo
+	job.framesMu.Lock()
+	if job.frames == nil {
+		extracted, err := extractRoundFrames(job.ctx, job.DemoPath)
+		if err != nil {
+			job.framesMu.Unlock()
+			writeError(w, http.StatusInternalServerError, "erro ao extrair frames: "+err.Error())
+			return
+		}
+		job.frames = extracted
+	}
+	cached := job.frames
+	job.framesMu.Unlock()
+
+	frames := cached.ByRound[round]
+	if frames == nil {
+		frames = []json.RawMessage{}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"map":    cached.Map,
+		"round":  round,
+		"frames": frames,
+	})
+}
+
+func (s *processorServer) handleCancel(w http.ResponseWriter, r *http.Request) {
+	job := s.lookup(w, r)
+	if job == nil {
+		return
+	}
+
+	s.mu.Lock()
+	if job.Status == jobQueued || job.Status == jobRunning {
+		// O worker marca como cancelado ao ver o contexto encerrado
+		job.cancel()
+		if job.Status == jobQueued {
+			s.finish(job, jobCancelled, "")
+		}
+	}
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, s.snapshot(job))
+}
+
+// handleDelete cancela o job (se ativo) e remove o job e a demo enviada por upload
+func (s *processorServer) handleDelete(w http.ResponseWriter, r *http.Request) {
+	job := s.lookup(w, r)
+	if job == nil {
+		return
+	}
+
+	s.mu.Lock()
+	job.cancel()
+	if job.Status == jobQueued {
+		s.finish(job, jobCancelled, "")
+	}
+	delete(s.jobs, job.ID)
+	running := job.Status == jobRunning
+	s.mu.Unlock()
+
+	// Job em execução: o worker apaga o upload quando o parse terminar
+	if job.uploaded && !running {
+		os.Remove(job.DemoPath)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *processorServer) worker() {
+	for job := range s.queue {
+		s.mu.Lock()
+		if job.Status != jobQueued {
+			s.mu.Unlock()
+			continue
+		}
+		job.Status = jobRunning
+		now := time.Now()
+		job.StartedAt = &now
+		s.mu.Unlock()
+
+		slog.Info("Job iniciado", "job", job.ID, "demo", job.DemoPath)
+		analysis, err := s.runJob(job)
+
+		s.mu.Lock()
+		switch {
+		case job.ctx.Err() != nil:
+			s.finish(job, jobCancelled, "")
+		case err != nil:
+			s.finish(job, jobFailed, err.Error())
+		default:
+			job.analysis = analysis
+			job.Partial = analysis.Metadata.Truncated
+			s.finish(job, jobCompleted, analysis.Metadata.Error)
+		}
+		_, stillListed := s.jobs[job.ID]
+		status := job.Status
+		evicted := s.evictFinished(time.Now())
+		s.mu.Unlock()
+
+		if !stillListed && job.uploaded {
+			os.Remove(job.DemoPath)
+		}
+		removeUploads(evicted)
+		slog.Info("Job finalizado", "job", job.ID, "status", status)
+	}
+}
+
+// janitor remove periodicamente os jobs finalizados que passaram do tempo de retenção
+func (s *processorServer) janitor() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		s.mu.Lock()
+		evicted := s.evictFinished(now)
+		s.mu.Unlock()
+		removeUploads(evicted)
+	}
+}
+
+// evictFinished tira da lista os jobs finalizados mais velhos que a retenção e, acima de
+// maxFinished, os que terminaram primeiro. Devolve os jobs removidos para apagar os uploads
+// fora do lock. Deve ser chamado com s.mu travado.
+func (s *processorServer) evictFinished(now time.Time) []*serveJob {
+	var finished, evicted []*serveJob
+	for _, job := range s.jobs {
+		if job.FinishedAt == nil || job.Status == jobQueued || job.Status == jobRunning {
+			continue
+		}
+		if s.retention > 0 && now.Sub(*job.FinishedAt) > s.retention {
+			evicted = append(evicted, job)
+			continue
+		}
+		finished = append(finished, job)
+	}
+	if s.maxFinished > 0 && len(finished) > s.maxFinished {
+		slices.SortFunc(finished, func(a, b *serveJob) int {
+			return a.FinishedAt.Compare(*b.FinishedAt)
+		})
+		evicted = append(evicted, finished[:len(finished)-s.maxFinished]...)
+	}
+	for _, job := range evicted {
+		delete(s.jobs, job.ID)
+		slog.Debug("Job finalizado removido", "job", job.ID, "status", job.Status)
+	}
+	return evicted
+}
+
+func removeUploads(jobs []*serveJob) {
+	for _, job := range jobs {
+		if job.uploaded {
+			os.Remove(job.DemoPath)
+		}
+	}
+}
+
+// runJob isola panics do parser para não derrubar o servidor
+func (s *processorServer) runJob(job *serveJob) (analysis *SimpleAnalysis, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	// O timeout não cancela job.ctx: o job termina como concluído com resultado parcial
+	ctx := job.ctx
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeoutCause(ctx, s.timeout, errTimeout)
+		defer cancel()
+	}
+	return analyzeDemo(ctx, job.DemoPath, analyzeOptions{
+		TargetSteamID: job.SteamID,
+		OnProgress: func(record ProgressRecord) {
+			s.mu.Lock()
+			job.Progress = &record
+			s.mu.Unlock()
+		},
+	})
+}
+
+// finish deve ser chamado com s.mu travado
+func (s *processorServer) finish(job *serveJob, status, errMsg string) {
+	job.Status = status
+	job.Error = errMsg
+	now := time.Now()
+	job.FinishedAt = &now
+}
+
+func (s *processorServer) lookup(w http.ResponseWriter, r *http.Request) *serveJob {
+	s.mu.Lock()
+	job := s.jobs[r.PathValue("id")]
+	s.mu.Unlock()
+	if job == nil {
+		writeError(w, http.StatusNotFound, "job não encontrado")
+	}
+	return job
+}
+
+// snapshot copia o status sob o lock para serializar sem corrida com o worker
+func (s *processorServer) snapshot(job *serveJob) JobStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return job.JobStatus
+}
+
+func (s *processorServer) saveUpload(file io.Reader, filename string) (string, error) {
+	out, err := os.CreateTemp(s.uploadDir, "upload-*-"+filepath.Base(filename))
+	if err != nil {
+		return "", err
+	}
+	defer out.Close()
+
+	if _, err := io.Copy(out, file); err != nil {
+		os.Remove(out.Name())
+		return "", err
+	}
+	return out.Name(), nil
+}
+
+// extractRoundFrames roda o binário extract-frames (ao lado do demo-processor) e separa os frames por round
+func extractRoundFrames(ctx context.Context, demoPath string) (*roundFrames, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	name := "extract-frames"
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	extractPath := filepath.Join(filepath.Dir(exe), name)
+	if _, err := os.Stat(extractPath); err != nil {
+		return nil, fmt.Errorf("%s não encontrado (compile com: go build -o %s extract-frames.go participants.go)", extractPath, name)
+	}
+
+	// O extract-frames lê só .dem sem compressão: descomprimir para um temporário antes
+	plainPath, cleanup, err := decompressedDemoPath(demoPath)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, extractPath, plainPath)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%v: %s", err, bytes.TrimSpace(stderr.Bytes()))
+	}
+
+	var data struct {
+		Map    string            `json:"map"`
+		Frames []json.RawMessage `json:"frames"`
+	}
+	if err := json.Unmarshal(out, &data); err != nil {
+		return nil, err
+	}
+
+	frames := &roundFrames{Map: data.Map, ByRound: make(map[int][]json.RawMessage)}
+	for _, raw := range data.Frames {
+		var frame struct {
+			Round int `json:"round"`
+		}
+		if err := json.Unmarshal(raw, &frame); err != nil {
+			return nil, err
+		}
+		frames.ByRound[frame.Round] = append(frames.ByRound[frame.Round], raw)
+	}
+	return frames, nil
+}
+
+// decompressedDemoPath devolve um caminho para a demo sem compressão (a própria, se já for .dem)
+func decompressedDemoPath(demoPath string) (string, func(), error) {
+	r, err := openDemo(demoPath)
+	if err != nil {
+		return "", nil, err
+	}
+	defer r.Close()
+
+	if _, plain := r.(*os.File); plain {
+		return demoPath, func() {}, nil
+	}
+
+	tmp, err := os.CreateTemp("", "demo-*.dem")
+	if err != nil {
+		return "", nil, err
+	}
+	defer tmp.Close()
+
+	cleanup := func() { os.Remove(tmp.Name()) }
+	if _, err := io.Copy(tmp, r); err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	return tmp.Name(), cleanup, nil
+}
+
+func parseSteamID(value string) uint64 {
+	steamID, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return steamID
+}
+
+func newJobID() string {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return hex.EncodeToString(buf)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Warn("Erro ao escrever resposta", "err", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func finishedJob(id, status string, finishedAt time.Time) *serveJob {
+	return &serveJob{JobStatus: JobStatus{ID: id, Status: status, FinishedAt: &finishedAt}}
+}
+
+// Jobs finalizados saem pela retenção e pelo limite; os ativos nunca são removidos
+func TestEvictFinished(t *testing.T) {
+	now := time.Now()
+	s := &processorServer{
+		jobs:        make(map[string]*serveJob),
+		retention:   time.Hour,
+		maxFinished: 2,
+	}
+	for _, job := range []*serveJob{
+		finishedJob("expired", jobCompleted, now.Add(-2*time.Hour)),
+		finishedJob("oldest", jobFailed, now.Add(-30*time.Minute)),
+		finishedJob("middle", jobCancelled, now.Add(-20*time.Minute)),
+		finishedJob("newest", jobCompleted, now.Add(-10*time.Minute)),
+		{JobStatus: JobStatus{ID: "running", Status: jobRunning}},
+		{JobStatus: JobStatus{ID: "queued", Status: jobQueued}},
+	} {
+		s.jobs[job.ID] = job
+	}
+
+	evicted := s.evictFinished(now)
+	if len(evicted) != 2 {
+		t.Errorf("removidos %d jobs, want 2", len(evicted))
+	}
+	for _, id := range []string{"middle", "newest", "running", "queued"} {
+		if s.jobs[id] == nil {
+			t.Errorf("job %s não deveria ser removido", id)
+		}
+	}
+	for _, id := range []string{"expired", "oldest"} {
+		if s.jobs[id] != nil {
+			t.Errorf("job %s deveria ser removido", id)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	common "github.com/markus-wa/demoinfocs-golang/v5/pkg/demoinfocs/common"
+)
+
+// statsAccumulator é o único lugar onde K/D/A, HS e dano dos jogadores são somados,
+// no total da partida, por lado e por arma. SimplePlayer e PlayerAnalysis só leem daqui.
+type statsAccumulator struct {
+	players map[uint64]*PlayerStats
+	sides   map[uint64]map[string]*SideStats
+	weapons map[uint64]map[string]*WeaponStats
+	// Último tick em que cada atacante acertou um inimigo (um disparo = um acerto)
+	lastHitTick map[uint64]int
+	// Dano em inimigos no round atual por par atacante/vítima (contribuição das assists)
+	roundDamage map[[2]uint64]int
+}
+
+func newStatsAccumulator() *statsAccumulator {
+	return &statsAccumulator{
+		players:     make(map[uint64]*PlayerStats),
+		sides:       make(map[uint64]map[string]*SideStats),
+		weapons:     make(map[uint64]map[string]*WeaponStats),
+		lastHitTick: make(map[uint64]int),
+		roundDamage: make(map[[2]uint64]int),
+	}
+}
+
+// get devolve os totais do participante (zerados se ainda não apareceu)
+func (a *statsAccumulator) get(id uint64) *PlayerStats {
+	stats, exists := a.players[id]
+	if !exists {
+		stats = &PlayerStats{}
+		a.players[id] = stats
+	}
+	return stats
+}
+
+// side devolve os números do lado em que o jogador está agora
+func (a *statsAccumulator) side(p *common.Player) *SideStats {
+	sides, exists := a.sides[participantID(p)]
+	if !exists {
+		sides = make(map[string]*SideStats)
+		a.sides[participantID(p)] = sides
+	}
+	side := teamToString(p.Team)
+	stats, exists := sides[side]
+	if !exists {
+		stats = &SideStats{}
+		sides[side] = stats
+	}
+	return stats
+}
+
+// weapon devolve os números do participante com uma arma
+func (a *statsAccumulator) weapon(id uint64, name string) *WeaponStats {
+	weapons, exists := a.weapons[id]
+	if !exists {
+		weapons = make(map[string]*WeaponStats)
+		a.weapons[id] = weapons
+	}
+	stats, exists := weapons[name]
+	if !exists {
+		stats = &WeaponStats{Weapon: name}
+		weapons[name] = stats
+	}
+	return stats
+}
+
+// kill registra uma morte. Só kill em inimigo conta para o killer (suicídio e team kill
+// não), e headshot sempre conta junto como kill. A assist é de flash quando o jogo marca
+// AssistedFlash; senão é de dano.
+func (a *statsAccumulator) kill(killer, victim, assister *common.Player, weapon string, headshot, flashAssist bool) {
+	if victim != nil {
+		a.get(participantID(victim)).Deaths++
+		a.side(victim).Deaths++
+		a.weapon(participantID(victim), weapon).Deaths++
+	}
+	if killer != nil && victim != nil && participantID(killer) != participantID(victim) && killer.Team != victim.Team {
+		stats := a.get(participantID(killer))
+		side := a.side(killer)
+		weaponStats := a.weapon(participantID(killer), weapon)
+		stats.Kills++
+		side.Kills++
+		weaponStats.Kills++
+		if headshot {
+			stats.HSKills++
+			side.HSKills++
+			weaponStats.Headshots++
+		}
+	}
+	if assister != nil {
+		stats := a.get(participantID(assister))
+		stats.Assists++
+		if flashAssist {
+			stats.FlashAssists++
+		}
+		stats.AssistDamage += a.assistDamage(assister, victim)
+	}
+}
+
+// assistDamage é o dano que o assistente causou na vítima no round atual
+func (a *statsAccumulator) assistDamage(assister, victim *common.Player) int {
+	if assister == nil || victim == nil {
+		return 0
+	}
+	return a.roundDamage[[2]uint64{participantID(assister), participantID(victim)}]
+}
+
+// damage registra dano de vida já limitado à vida da vítima, separado em inimigo/time/próprio.
+// Acertos só contam para armas de fogo: granadas e fogo não têm um disparo por dano.
+func (a *statsAccumulator) damage(attacker, victim *common.Player, weapon *common.Equipment, amount, tick int) {
+	stats := a.get(participantID(attacker))
+	switch {
+	case participantID(attacker) == participantID(victim):
+		stats.SelfDamage += amount
+	case attacker.Team == victim.Team:
+		stats.TeamDamage += amount
+	default:
+		stats.Damage += amount
+		a.side(attacker).Damage += amount
+		weaponStats := a.weapon(participantID(attacker), weaponName(weapon))
+		weaponStats.Damage += amount
+		if last, exists := a.lastHitTick[participantID(attacker)]; isGun(weapon) && (!exists || last != tick) {
+			weaponStats.Hits++
+			a.lastHitTick[participantID(attacker)] = tick
+		}
+		a.roundDamage[[2]uint64{participantID(attacker), participantID(victim)}] += amount
+	}
+}
+
+// shot conta um disparo (WeaponFire) de arma de fogo; faca e granadas não têm
+// disparos nem precisão, como em damage
+func (a *statsAccumulator) shot(shooter *common.Player, weapon *common.Equipment) {
+	if !isGun(weapon) {
+		return
+	}
+	a.weapon(participantID(shooter), weaponName(weapon)).Shots++
+}
+
+// roundPlayed conta um round oficial no lado atual do jogador
+func (a *statsAccumulator) roundPlayed(p *common.Player) {
+	a.side(p).Rounds++
+}
+
+// newRound zera o dano do round (cada vítima só morre uma vez por round)
+func (a *statsAccumulator) newRound() {
+	clear(a.roundDamage)
+}
+
+// reset descarta tudo (restart da partida)
+func (a *statsAccumulator) reset() {
+	clear(a.players)
+	clear(a.sides)
+	clear(a.weapons)
+	clear(a.lastHitTick)
+	clear(a.roundDamage)
+}
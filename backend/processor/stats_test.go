@@ -0,0 +1,254 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	common "github.com/markus-wa/demoinfocs-golang/v5/pkg/demoinfocs/common"
+	events "github.com/markus-wa/demoinfocs-golang/v5/pkg/demoinfocs/events"
+	st "github.com/markus-wa/demoinfocs-golang/v5/pkg/demoinfocs/sendtables"
+)
+
+var (
+	ak47    = common.NewEquipment(common.EqAK47)
+	glock   = common.NewEquipment(common.EqGlock)
+	molotov = common.NewEquipment(common.EqMolotov)
+	he      = common.NewEquipment(common.EqHE)
+	knife   = common.NewEquipment(common.EqKnife)
+)
+
+func testPlayer(steamID uint64, team common.Team) *common.Player {
+	return &common.Player{SteamID64: steamID, Name: "p", Team: team}
+}
+
+// Eventos de uma partida curta com números conhecidos
+func TestStatsAccumulatorKnownAnswers(t *testing.T) {
+	t1 := testPlayer(1, common.TeamTerrorists)
+	t2 := testPlayer(2, common.TeamTerrorists)
+	ct1 := testPlayer(3, common.TeamCounterTerrorists)
+	ct2 := testPlayer(4, common.TeamCounterTerrorists)
+
+	acc := newStatsAccumulator()
+	tick := 0
+	hurt := func(attacker, victim *common.Player, amount int) {
+		tick++
+		acc.damage(attacker, victim, ak47, amount, tick)
+	}
+
+	// Round 1: t1 tira 60 de ct1, t2 finaliza com headshot (assist de t1)
+	acc.newRound()
+	hurt(t1, ct1, 60)
+	hurt(t2, ct1, 40)
+	acc.kill(t2, ct1, t1, "AK-47", true, false)
+	// ct2 mata t2 sem headshot
+	hurt(ct2, t2, 100)
+	acc.kill(ct2, t2, nil, "M4A4", false, false)
+	// t1 mata ct2 com headshot, assist por flash de quem não causou dano
+	hurt(t1, ct2, 100)
+	acc.kill(t1, ct2, t2, "AK-47", true, true)
+
+	// Round 2: team damage, self damage, team kill e suicídio não contam como kill
+	acc.newRound()
+	hurt(t1, t2, 30)
+	hurt(ct1, ct1, 20)
+	acc.kill(t1, t2, nil, "AK-47", false, false)
+	acc.kill(ct1, ct1, nil, "HE Grenade", false, false)
+	// Kill sem killer (mundo) conta só a morte
+	acc.kill(nil, ct2, nil, "World", false, false)
+
+	want := map[uint64]PlayerStats{
+		1: {Kills: 1, Deaths: 0, Assists: 1, HSKills: 1, Damage: 160, TeamDamage: 30, AssistDamage: 60},
+		2: {Kills: 1, Deaths: 2, Assists: 1, FlashAssists: 1, HSKills: 1, Damage: 40},
+		3: {Kills: 0, Deaths: 2, HSKills: 0, SelfDamage: 20},
+		4: {Kills: 1, Deaths: 2, Damage: 100},
+	}
+	for id, expected := range want {
+		got := *acc.get(id)
+		if got != expected {
+			t.Errorf("player %d: got %+v, want %+v", id, got, expected)
+		}
+	}
+
+	if side := acc.sides[1]["T"]; side.Kills != 1 || side.HSKills != 1 || side.Damage != 160 {
+		t.Errorf("lado T de t1: %+v", *side)
+	}
+	if side := acc.sides[4]["CT"]; side.Kills != 1 || side.Deaths != 2 || side.Damage != 100 {
+		t.Errorf("lado CT de ct2: %+v", *side)
+	}
+}
+
+// Headshot sempre conta também como kill
+func TestStatsAccumulatorHeadshotIsKill(t *testing.T) {
+	killer := testPlayer(1, common.TeamTerrorists)
+	acc := newStatsAccumulator()
+	for i := range 3 {
+		acc.kill(killer, testPlayer(uint64(10+i), common.TeamCounterTerrorists), nil, "Deagle", true, false)
+	}
+	got := acc.get(1)
+	if got.Kills != 3 || got.HSKills != 3 {
+		t.Fatalf("got kills=%d hs=%d, want 3/3", got.Kills, got.HSKills)
+	}
+}
+
+// O dano do round por par atacante/vítima zera a cada round e alimenta a assist
+func TestStatsAccumulatorAssistDamagePerRound(t *testing.T) {
+	attacker := testPlayer(1, common.TeamTerrorists)
+	victim := testPlayer(2, common.TeamCounterTerrorists)
+	acc := newStatsAccumulator()
+
+	acc.damage(attacker, victim, glock, 50, 1)
+	acc.newRound()
+	acc.damage(attacker, victim, glock, 30, 2)
+	if got := acc.assistDamage(attacker, victim); got != 30 {
+		t.Fatalf("assistDamage = %d, want 30", got)
+	}
+
+	acc.reset()
+	if got := *acc.get(1); got != (PlayerStats{}) {
+		t.Fatalf("reset deixou %+v", got)
+	}
+}
+
+// fakeEntity expõe só as propriedades do mapa; o resto da interface não é usado
+type fakeEntity struct {
+	st.Entity
+	props map[string]any
+}
+
+func (e fakeEntity) PropertyValue(name string) (st.PropertyValue, bool) {
+	v, ok := e.props[name]
+	return st.PropertyValue{Any: v}, ok
+}
+
+// scoreboardRound é um round gravado como o jogo manda: os campos do evento player_hurt
+// e o m_iDamage de cada jogador no fim do round (testdata/scoreboard-round.json)
+type scoreboardRound struct {
+	Players []struct {
+		ID       uint64 `json:"id"`
+		Team     string `json:"team"`
+		MIDamage int32  `json:"m_iDamage"`
+	} `json:"players"`
+	PlayerHurt []struct {
+		Attacker  uint64 `json:"attacker"`
+		UserID    uint64 `json:"userid"`
+		DmgHealth int    `json:"dmg_health"`
+		Health    int    `json:"health"` // Vida da vítima depois do dano
+	} `json:"player_hurt"`
+}
+
+// O dano somado pelo accumulator bate com o m_iDamage lido por scoreboardDamageOf:
+// só dano de vida em inimigos, sem over-damage, contando molotov depois da morte do atacante.
+// O HealthDamageTaken é montado como o parser faz (limite de 100 e, no dano fatal, a vida
+// que a vítima tinha).
+func TestDamageMatchesScoreboard(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "scoreboard-round.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var round scoreboardRound
+	if err := json.Unmarshal(data, &round); err != nil {
+		t.Fatal(err)
+	}
+
+	players := make(map[uint64]*common.Player)
+	health := make(map[uint64]int)
+	for _, p := range round.Players {
+		team := common.TeamTerrorists
+		if p.Team == "CT" {
+			team = common.TeamCounterTerrorists
+		}
+		players[p.ID] = testPlayer(p.ID, team)
+		players[p.ID].Entity = fakeEntity{props: map[string]any{"m_pActionTrackingServices.m_iDamage": p.MIDamage}}
+		health[p.ID] = 100
+	}
+
+	acc := newStatsAccumulator()
+	for i, hurt := range round.PlayerHurt {
+		taken := min(hurt.DmgHealth, 100)
+		if hurt.Health == 0 {
+			taken = health[hurt.UserID]
+		}
+		health[hurt.UserID] = hurt.Health
+		e := events.PlayerHurt{
+			Attacker:          players[hurt.Attacker],
+			Player:            players[hurt.UserID],
+			Health:            hurt.Health,
+			HealthDamage:      hurt.DmgHealth,
+			HealthDamageTaken: taken,
+		}
+		acc.damage(e.Attacker, e.Player, ak47, healthDamageTaken(e), i)
+	}
+
+	for id, player := range players {
+		scoreboard, ok := scoreboardDamageOf(player)
+		if !ok {
+			t.Fatalf("player %d sem m_iDamage", id)
+		}
+		if got := acc.get(id).Damage; got != scoreboard {
+			t.Errorf("player %d: damage %d, placar %d", id, got, scoreboard)
+		}
+	}
+}
+
+func TestScoreboardDamageOfMissingProperty(t *testing.T) {
+	p := testPlayer(1, common.TeamTerrorists)
+	if _, ok := scoreboardDamageOf(p); ok {
+		t.Error("sem entidade não deveria ter dano do placar")
+	}
+
+	p.Entity = fakeEntity{props: map[string]any{}}
+	if _, ok := scoreboardDamageOf(p); ok {
+		t.Error("sem m_iDamage não deveria ter dano do placar")
+	}
+
+	p.Entity = fakeEntity{props: map[string]any{"m_pActionTrackingServices.m_iDamage": int32(342)}}
+	if damage, ok := scoreboardDamageOf(p); !ok || damage != 342 {
+		t.Errorf("got %d %v, want 342", damage, ok)
+	}
+}
+
+// Disparos, acertos e precisão só contam armas de fogo: cada tick de molotov e cada HE
+// geram PlayerHurt sem um WeaponFire correspondente, e golpe de faca não é disparo
+func TestWeaponHitsOnlyForGuns(t *testing.T) {
+	attacker := testPlayer(1, common.TeamTerrorists)
+	victim := testPlayer(2, common.TeamCounterTerrorists)
+	other := testPlayer(3, common.TeamCounterTerrorists)
+	acc := newStatsAccumulator()
+
+	// Um molotov queimando por 8 ticks e uma HE pegando dois inimigos
+	acc.shot(attacker, molotov)
+	for tick := 1; tick <= 8; tick++ {
+		acc.damage(attacker, victim, molotov, 4, tick)
+	}
+	acc.shot(attacker, he)
+	acc.shot(attacker, knife)
+	acc.damage(attacker, victim, he, 20, 20)
+	acc.damage(attacker, other, he, 25, 20)
+
+	// 4 disparos de AK, 2 acertos (um deles wallbang em dois inimigos no mesmo tick)
+	for range 4 {
+		acc.shot(attacker, ak47)
+	}
+	acc.damage(attacker, victim, ak47, 27, 30)
+	acc.damage(attacker, other, ak47, 20, 30)
+	acc.damage(attacker, victim, ak47, 27, 35)
+
+	weapons := map[string]WeaponStats{}
+	for _, w := range sortedWeapons(acc.weapons[1]) {
+		weapons[w.Weapon] = w
+	}
+	if w := weapons[weaponName(molotov)]; w.Shots != 0 || w.Hits != 0 || w.Accuracy != 0 || w.Damage != 32 {
+		t.Errorf("molotov: %+v", w)
+	}
+	if w := weapons[weaponName(he)]; w.Shots != 0 || w.Hits != 0 || w.Accuracy != 0 || w.Damage != 45 {
+		t.Errorf("HE: %+v", w)
+	}
+	if _, swung := weapons[weaponName(knife)]; swung {
+		t.Errorf("faca sem dano não deveria aparecer: %+v", weapons[weaponName(knife)])
+	}
+	if w := weapons[weaponName(ak47)]; w.Shots != 4 || w.Hits != 2 || w.Accuracy != 50 {
+		t.Errorf("AK-47: %+v", w)
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	demoinfocs "github.com/markus-wa/demoinfocs-golang/v5/pkg/demoinfocs"
+	common "github.com/markus-wa/demoinfocs-golang/v5/pkg/demoinfocs/common"
+)
+
+// MatchTeam é um time da partida, estável entre trocas de lado.
+// "A" é o time que começou o primeiro round oficial de CT, "B" o que começou de T.
+// Players lista só os participantes que entram em SimpleAnalysis.Players.
+type MatchTeam struct {
+	ID        string   `json:"id"`
+	Name      string   `json:"name,omitempty"` // Clan name informado pelo servidor
+	StartSide string   `json:"startSide"`
+	Score     int      `json:"score"`
+	Players   []uint64 `json:"players"`
+}
+
+// HalfSide é o lado em que o jogador jogou uma metade
+type HalfSide struct {
+	Overtime int    `json:"overtime,omitempty"`
+	Half     int    `json:"half"`
+	Side     string `json:"side"`
+}
+
+// roundTeams guarda quem estava em cada lado num round oficial
+type roundTeams struct {
+	ctName string
+	tName  string
+	sides  map[uint64]string
+}
+
+// observeRoundTeams registra os lados dos jogadores e os clan names no início do round
+func observeRoundTeams(gs demoinfocs.GameState, players []*common.Player) roundTeams {
+	teams := roundTeams{sides: make(map[uint64]string)}
+	if ct := gs.TeamCounterTerrorists(); ct != nil {
+		teams.ctName = ct.ClanName()
+	}
+	if t := gs.TeamTerrorists(); t != nil {
+		teams.tName = t.ClanName()
+	}
+	for _, player := range players {
+		if player == nil || participantID(player) == 0 || isCoach(player) {
+			continue
+		}
+		if player.Team == common.TeamTerrorists || player.Team == common.TeamCounterTerrorists {
+			teams.sides[participantID(player)] = teamToString(player.Team)
+		}
+	}
+	return teams
+}
+
+// playerTeam é o time e os lados por metade de um jogador
+type playerTeam struct {
+	TeamID    string
+	HalfSides []HalfSide
+}
+
+// buildTeams monta os times A/B a partir dos rounds oficiais (em ordem).
+// O lado de cada time em um round sai dos elencos observados (quem do time A está de CT
+// e de T); só rounds sem elenco observado usam as trocas de lado desde o último round
+// resolvido, então uma troca de lado perdida ou a mais não inverte o resto da partida.
+func buildTeams(rounds []RoundInfo, observed map[int]roundTeams) ([]MatchTeam, map[uint64]*playerTeam) {
+	players := make(map[uint64]*playerTeam)
+	if len(rounds) == 0 {
+		return []MatchTeam{}, players
+	}
+
+	teamA := &MatchTeam{ID: "A", StartSide: "CT", Players: []uint64{}}
+	teamB := &MatchTeam{ID: "B", StartSide: "T", Players: []uint64{}}
+	roundsOnA := make(map[uint64]int)
+	roundsOnB := make(map[uint64]int)
+	lastSideA, lastSwaps := "CT", rounds[0].swaps
+
+	for _, info := range rounds {
+		sideA := lastSideA
+		if (info.swaps-lastSwaps)%2 != 0 {
+			sideA = oppositeSide(sideA)
+		}
+		if teams, exists := observed[info.Round]; exists {
+			sideA = sideByRoster(teams.sides, roundsOnA, roundsOnB, sideA)
+		}
+		lastSideA, lastSwaps = sideA, info.swaps
+
+		if info.Winner != "" {
+			if info.Winner == sideA {
+				teamA.Score++
+			} else {
+				teamB.Score++
+			}
+		}
+
+		teams, exists := observed[info.Round]
+		if !exists {
+			continue
+		}
+		nameA, nameB := teams.ctName, teams.tName
+		if sideA == "T" {
+			nameA, nameB = nameB, nameA
+		}
+		if teamA.Name == "" {
+			teamA.Name = nameA
+		}
+		if teamB.Name == "" {
+			teamB.Name = nameB
+		}
+
+		for steamID, side := range teams.sides {
+			if side == sideA {
+				roundsOnA[steamID]++
+			} else {
+				roundsOnB[steamID]++
+			}
+
+			player, exists := players[steamID]
+			if !exists {
+				player = &playerTeam{HalfSides: []HalfSide{}}
+				players[steamID] = player
+			}
+			// Primeiro lado visto em cada metade
+			last := len(player.HalfSides) - 1
+			if last < 0 || player.HalfSides[last].Overtime != info.Overtime || player.HalfSides[last].Half != info.Half {
+				player.HalfSides = append(player.HalfSides, HalfSide{Overtime: info.Overtime, Half: info.Half, Side: side})
+			}
+		}
+	}
+
+	for steamID, player := range players {
+		if roundsOnA[steamID] >= roundsOnB[steamID] {
+			player.TeamID = "A"
+		} else {
+			player.TeamID = "B"
+		}
+	}
+
+	return []MatchTeam{*teamA, *teamB}, players
+}
+
+// sideByRoster decide o lado do time A no round pelo elenco: jogadores que já jogaram mais
+// rounds por A contam para o lado em que estão agora, os de B para o lado oposto. Sem
+// ninguém conhecido (primeiro round, elenco todo novo) ou empate, fica o lado pelas trocas.
+func sideByRoster(sides map[uint64]string, roundsOnA, roundsOnB map[uint64]int, fallback string) string {
+	votes := map[string]int{}
+	for steamID, side := range sides {
+		switch onA, onB := roundsOnA[steamID], roundsOnB[steamID]; {
+		case onA > onB:
+			votes[side]++
+		case onB > onA:
+			votes[oppositeSide(side)]++
+		}
+	}
+	switch {
+	case votes["CT"] > votes["T"]:
+		return "CT"
+	case votes["T"] > votes["CT"]:
+		return "T"
+	}
+	return fallback
+}
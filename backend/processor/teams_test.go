@@ -0,0 +1,76 @@
+package main
+
+import "testing"
+
+// observedSides monta o elenco de um round: ids de A no lado sideA, ids de B no oposto
+func observedSides(sideA string, teamA, teamB []uint64) roundTeams {
+	teams := roundTeams{sides: make(map[uint64]string)}
+	for _, id := range teamA {
+		teams.sides[id] = sideA
+	}
+	for _, id := range teamB {
+		teams.sides[id] = oppositeSide(sideA)
+	}
+	return teams
+}
+
+// O lado de cada time sai do elenco observado: um TeamSideSwitch perdido no halftime ou
+// um a mais no meio da metade não inverte placar nem times do resto da partida
+func TestBuildTeamsBySidesNotSwitchParity(t *testing.T) {
+	teamA := []uint64{1, 2, 3, 4, 5}
+	teamB := []uint64{6, 7, 8, 9, 10}
+
+	tests := []struct {
+		name  string
+		swaps func(round int) int
+	}{
+		{"halftime perdido", func(int) int { return 0 }},
+		{"troca a mais no round 5", func(round int) int {
+			swaps := 0
+			if round >= 5 {
+				swaps++
+			}
+			if round >= 13 {
+				swaps++
+			}
+			return swaps
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rounds := []RoundInfo{}
+			observed := make(map[int]roundTeams)
+			for round := 1; round <= 24; round++ {
+				sideA := "CT"
+				if round >= 13 {
+					sideA = "T"
+				}
+				// A vence 16 rounds: todos da 1ª metade e 4 da 2ª
+				winner := oppositeSide(sideA)
+				if round <= 16 {
+					winner = sideA
+				}
+				rounds = append(rounds, RoundInfo{Round: round, Half: 1, Winner: winner, swaps: tt.swaps(round)})
+				// Round 20 sem elenco observado: lado pelas trocas desde o round anterior
+				if round != 20 {
+					observed[round] = observedSides(sideA, teamA, teamB)
+				}
+			}
+
+			teams, players := buildTeams(rounds, observed)
+			if teams[0].Score != 16 || teams[1].Score != 8 {
+				t.Errorf("placar A %d x %d B, want 16 x 8", teams[0].Score, teams[1].Score)
+			}
+			for _, id := range teamA {
+				if players[id].TeamID != "A" {
+					t.Errorf("jogador %d no time %s, want A", id, players[id].TeamID)
+				}
+			}
+			for _, id := range teamB {
+				if players[id].TeamID != "B" {
+					t.Errorf("jogador %d no time %s, want B", id, players[id].TeamID)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"sort"
+
+	common "github.com/markus-wa/demoinfocs-golang/v5/pkg/demoinfocs/common"
+)
+
+// WeaponStats são os números de um jogador com uma arma (só rounds oficiais).
+// Hits conta disparos que acertaram inimigo: vários PlayerHurt do mesmo atacante no mesmo
+// tick (chumbos de shotgun, wallbang em dois) contam como um acerto.
+type WeaponStats struct {
+	Weapon    string  `json:"weapon"`
+	Kills     int     `json:"kills"`
+	Headshots int     `json:"headshots"`
+	Damage    int     `json:"damage"` // Dano em inimigos
+	Shots     int     `json:"shots"`  // Disparos (WeaponFire) de arma de fogo
+	Hits      int     `json:"hits"`
+	Accuracy  float64 `json:"accuracy"` // Hits / Shots em %
+	Deaths    int     `json:"deaths"`   // Mortes sofridas para esta arma
+}
+
+// weaponName é o nome da arma como aparece nos eventos ("unknown" sem arma)
+func weaponName(w *common.Equipment) string {
+	if w == nil {
+		return "unknown"
+	}
+	return w.Type.String()
+}
+
+// sortedWeapons lista as armas por kills e depois por dano, calculando a precisão
+func sortedWeapons(weapons map[string]*WeaponStats) []WeaponStats {
+	list := make([]WeaponStats, 0, len(weapons))
+	for _, weapon := range weapons {
+		stats := *weapon
+		if stats.Shots > 0 {
+			stats.Accuracy = float64(stats.Hits) / float64(stats.Shots) * 100
+		}
+		list = append(list, stats)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Kills != list[j].Kills {
+			return list[i].Kills > list[j].Kills
+		}
+		if list[i].Damage != list[j].Damage {
+			return list[i].Damage > list[j].Damage
+		}
+		return list[i].Weapon < list[j].Weapon
+	})
+	return list
+}
@@ -9,7 +9,49 @@ interface GoMetadata {
   scoreCT: number;
   warmupRounds?: number;  // Número de rounds de aquecimento
   knifeRound?: boolean;   // Se tem round de faca
+  knife?: {              // Round de faca: vencedor e lado escolhido
+    round: number;
+    winner: string;
+    winnerTeam?: string;
+    chosenSide?: string;
+    switched: boolean;
+  };
+  regulation?: GoPeriodScore;        // Placar do tempo regulamentar
+  overtimes?: number;                // Prorrogações jogadas
+  overtimeScores?: GoPeriodScore[];  // Placar de cada prorrogação
+  firstOfficialRound?: number; // Primeiro round depois do aquecimento/restarts (regras do jogo)
   source?: string;        // "GC" ou "Valve"
+  truncated?: boolean;    // Demo truncada/corrompida: resultado parcial
+  error?: string;         // Descrição do erro que interrompeu o parse
+  diagnostics?: {         // Avisos do parser (demo danificada quando há muitos)
+    total: number;
+    byKind: Record<string, number>;
+    samples: { kind: string; message: string; round: number; tick: number }[];
+  };
+}
+
+// Placar de um período; lados = onde cada time terminou a partida (como scoreT/scoreCT)
+interface GoPeriodScore {
+  overtime: number;
+  scoreT: number;
+  scoreCT: number;
+}
+
+// Time estável: "A" começou de CT, "B" de T; score é o placar do time na partida
+interface GoTeam {
+  id: string;
+  name?: string;
+  startSide: string;
+  score: number;
+  players: number[];
+}
+
+interface GoRound {
+  round: number;
+  half: number;        // 1 ou 2 dentro do período
+  overtime?: number;   // 0/ausente = tempo regulamentar
+  sideSwap?: boolean;  // Primeiro round depois de troca de lado
+  winner?: string;     // "T" ou "CT"
 }
 
 interface GoEvent {
@@ -17,6 +59,8 @@ interface GoEvent {
   time: number;
   tick: number;
   round: number;
+  half?: number;       // Metade do período (rounds oficiais)
+  overtime?: number;   // Prorrogação (ausente = tempo regulamentar)
   isWarmup?: boolean;  // Se o evento é de round de aquecimento
   isKnife?: boolean;   // Se o evento é de round de faca
   data?: any;
@@ -25,10 +69,120 @@ interface GoEvent {
 interface GoPlayer {
   steamID: number;
   name: string;
-  team: string;
+  role?: string;      // "player" ou "bot" (coaches/espectadores não vêm na lista)
+  isBot?: boolean;
+  team: string;       // Lado no fim da partida ("T", "CT" ou "SPEC")
+  teamId?: string;    // Time estável entre trocas de lado ("A" ou "B")
+  halfSides?: { overtime?: number; half: number; side: string }[];
   kills: number;
   deaths: number;
   assists: number;
+  flashAssists?: number;  // Assists por flash (o resto é assist de dano)
+  assistDamage?: number;  // Dano causado nas vítimas das assists
+  hsKills?: number;
+  damage?: number;            // Dano em inimigos (base do ADR)
+  teamDamage?: number;
+  selfDamage?: number;
+  scoreboardDamage?: number;  // Dano do placar do jogo, para conferência
+  adr?: number;
+  rounds?: number;  // Rounds oficiais jogados
+  sides?: Record<string, { rounds: number; kills: number; deaths: number; hsKills: number; damage: number }>;
+  weapons?: GoWeaponStats[];  // Por arma, calculado pelo processador
+  aim?: GoAimStats;           // Precisão e spray (armas de fogo)
+  reaction?: {               // Tempo entre avistar o inimigo e atirar/acertar
+    engagements: number;
+    reactions: number;
+    medianReactionMs: number;
+    damageEngagements: number;
+    medianTimeToDamageMs: number;
+  };
+  crosshair?: {              // Erro de mira ao avistar o inimigo
+    engagements: number;
+    medianErrorDeg: number;
+    medianShotErrorDeg: number;
+    score: number;            // 100 = mira na cabeça, 0 = 30° ou mais
+  };
+  duels?: {                  // Vitórias em duelos decididos por classe de arma e distância
+    duels: number;
+    decided: number;
+    won: number;
+    winRate: number;
+    byWeapon: GoDuelBucket[];
+    byDistance: GoDuelBucket[];
+  };
+  awareness?: {              // Tempo visto por inimigos e mortes sem ver o killer
+    aliveSeconds: number;
+    seenSeconds: number;
+    seenPct: number;
+    deaths: number;
+    unawareDeaths: number;
+    unawarePct: number;
+  };
+}
+
+interface GoAccuracyStat {
+  shots: number;
+  hits: number;
+  accuracy: number;
+}
+
+interface GoAimStats extends GoAccuracyStat {
+  firstBullet: GoAccuracyStat;
+  spray: (GoAccuracyStat & { bullet: number })[];
+  movingShots: number;
+  movingPct: number;
+  movement?: { rifles: GoMovementBreakdown; pistols: GoMovementBreakdown };
+}
+
+// Disparos parados, com counter-strafe e em movimento
+interface GoMovementBreakdown {
+  shots: number;
+  stationary: number;
+  counterStrafed: number;
+  moving: number;
+  stationaryPct: number;
+  counterStrafedPct: number;
+  movingPct: number;
+}
+
+interface GoWeaponStats {
+  weapon: string;
+  kills: number;
+  headshots: number;
+  damage: number;
+  shots: number;
+  hits: number;
+  accuracy: number;
+  deaths: number;
+}
+
+interface GoDuelBucket {
+  key: string;
+  decided: number;
+  won: number;
+  winRate: number;
+}
+
+interface GoDuel {
+  round: number;
+  startTick: number;
+  endTick: number;
+  initiator: number;
+  winner?: number;       // Ausente quando ninguém morreu no duelo
+  distance: number;
+  range: string;         // "close", "medium" ou "long"
+  players: { steamID: number; damage: number; weapon: string; weaponClass: string; blind: boolean }[];
+}
+
+interface GoCrosshairEngagement {
+  round: number;
+  tick: number;
+  steamID: number;
+  enemySteamID: number;
+  errorDeg: number;
+  shotErrorDeg?: number;
+  timeToShotMs?: number;
+  samples: { timeMs: number; errorDeg: number }[];
 }
 
 interface GoHeatmapPoint {
@@ -42,6 +196,10 @@ interface GoHeatmapPoint {
 interface GoAnalysis {
   metadata: GoMetadata;
   events: GoEvent[];
+  rounds?: GoRound[];
+  teams?: GoTeam[];
+  crosshair?: GoCrosshairEngagement[];  // Mira em cada contato (avistamento até o primeiro disparo)
+  duels?: GoDuel[];                     // Trocas de dano entre dois inimigos
   players: GoPlayer[];
   summary: {
     mvp: string;
@@ -116,12 +274,18 @@ export const convertGoDataToAnalysisData = (goData: GoAnalysis, type: AnalysisTy
     }
   });
   
-  // GC: Ignorar primeiros 4 rounds (aquecimento), começar do round 5
-  // MM: Usar todos os rounds oficiais desde o início
+  // O Go informa o primeiro round oficial a partir das regras do jogo (warmup/restart).
+  // Sem esse campo (resultados antigos): GC ignora os 4 primeiros rounds, MM usa todos
   let firstOfficialRound: number;
   const officialRounds = new Set<number>();
   
-  if (isGC) {
+  if (metadata.firstOfficialRound && metadata.firstOfficialRound > 0) {
+    firstOfficialRound = metadata.firstOfficialRound;
+    for (let r = firstOfficialRound; r <= lastRound; r++) {
+      officialRounds.add(r);
+    }
+    console.log(`[DEBUG] Primeiro round oficial (regras do jogo): ${firstOfficialRound}, contando até ${lastRound} (${officialRounds.size} rounds)`);
+  } else if (isGC) {
     // GC: rounds 5 em diante são oficiais
     firstOfficialRound = 5;
     for (let r = firstOfficialRound; r <= lastRound; r++) {
@@ -339,8 +503,10 @@ export const convertGoDataToAnalysisData = (goData: GoAnalysis, type: AnalysisTy
           ? (event.data.assister as any).steamID
           : null)
         : null;
-      // Assist pode vir como string (nome) ou objeto, vamos procurar pelo nome no playerMap
-      if (event.data.assister && typeof event.data.assister === 'string' && event.data.assister.trim() !== '') {
+      if (assisterSteamID) {
+        playerAssistsMap.set(assisterSteamID, (playerAssistsMap.get(assisterSteamID) || 0) + 1);
+      } else if (event.data.assister && typeof event.data.assister === 'string' && event.data.assister.trim() !== '') {
+        // Demos processadas antes mandavam só o nome do assistente
         const assisterName = event.data.assister;
         const assisterPlayer = players.find(p => p.name === assisterName);
         if (assisterPlayer) {
@@ -778,45 +944,64 @@ export const convertGoDataToAnalysisData = (goData: GoAnalysis, type: AnalysisTy
     });
   });
   
-  // 4. WEAPON STATS: Kills por arma
-  const weaponStatsMap = new Map<string, { kills: number; headshots: number; damage: number }>();
-  const playerWeaponStatsMap = new Map<number, Map<string, { kills: number; headshots: number; damage: number }>>();
+  // 4. WEAPON STATS: vêm do processador (kills, HS, dano, disparos, acertos e mortes por arma).
+  // Saídas antigas sem "weapons" caem na estimativa pelos eventos de kill.
+  const playerWeaponStatsMap = new Map<number, Map<string, WeaponStats>>();
+  const hasGoWeapons = goData.players.some(p => Array.isArray(p.weapons));
   
-  officialEvents.forEach(event => {
-    if (event.type === 'kill' && event.data?.killer && event.data?.weapon) {
-      const weapon = event.data.weapon as string;
-      const killerSteamID = event.data.killer.steamID;
-      const isHeadshot = event.data.headshot === true || event.data.headshot === 'true';
-      const damage = event.data.damage || 100; // Assumir 100 se não tiver
-      
-      if (!weaponStatsMap.has(weapon)) {
-        weaponStatsMap.set(weapon, { kills: 0, headshots: 0, damage: 0 });
+  if (hasGoWeapons) {
+    goData.players.forEach(p => {
+      const playerWeapons = new Map<string, WeaponStats>();
+      (p.weapons || []).forEach(w => playerWeapons.set(w.weapon, { ...w }));
+      playerWeaponStatsMap.set(p.steamID, playerWeapons);
+    });
+  } else {
+    officialEvents.forEach(event => {
+      if (event.type === 'kill' && event.data?.killer && event.data?.weapon) {
+        const weapon = event.data.weapon as string;
+        const killerSteamID = event.data.killer.steamID;
+        const isHeadshot = event.data.headshot === true || event.data.headshot === 'true';
+        const damage = event.data.damage || 100; // Assumir 100 se não tiver
+        
+        if (!playerWeaponStatsMap.has(killerSteamID)) {
+          playerWeaponStatsMap.set(killerSteamID, new Map());
+        }
+        const playerWeapons = playerWeaponStatsMap.get(killerSteamID)!;
+        if (!playerWeapons.has(weapon)) {
+          playerWeapons.set(weapon, { weapon, kills: 0, headshots: 0, damage: 0 });
+        }
+        const playerWeaponStats = playerWeapons.get(weapon)!;
+        playerWeaponStats.kills++;
+        if (isHeadshot) playerWeaponStats.headshots++;
+        playerWeaponStats.damage += damage;
       }
-      const stats = weaponStatsMap.get(weapon)!;
-      stats.kills++;
-      if (isHeadshot) stats.headshots++;
-      stats.damage += damage;
-      
-      if (!playerWeaponStatsMap.has(killerSteamID)) {
-        playerWeaponStatsMap.set(killerSteamID, new Map());
+    });
+  }
+  
+  // Total da partida por arma
+  const weaponStatsMap = new Map<string, WeaponStats>();
+  playerWeaponStatsMap.forEach(playerWeapons => {
+    playerWeapons.forEach(w => {
+      if (!weaponStatsMap.has(w.weapon)) {
+        weaponStatsMap.set(w.weapon, hasGoWeapons
+          ? { weapon: w.weapon, kills: 0, headshots: 0, damage: 0, shots: 0, hits: 0, accuracy: 0, deaths: 0 }
+          : { weapon: w.weapon, kills: 0, headshots: 0, damage: 0 });
       }
-      const playerWeapons = playerWeaponStatsMap.get(killerSteamID)!;
-      if (!playerWeapons.has(weapon)) {
-        playerWeapons.set(weapon, { kills: 0, headshots: 0, damage: 0 });
+      const stats = weaponStatsMap.get(w.weapon)!;
+      stats.kills += w.kills;
+      stats.headshots += w.headshots;
+      stats.damage += w.damage;
+      if (hasGoWeapons) {
+        stats.shots! += w.shots || 0;
+        stats.hits! += w.hits || 0;
+        stats.deaths! += w.deaths || 0;
+        stats.accuracy = stats.shots! > 0 ? (stats.hits! / stats.shots!) * 100 : 0;
       }
-      const playerWeaponStats = playerWeapons.get(weapon)!;
-      playerWeaponStats.kills++;
-      if (isHeadshot) playerWeaponStats.headshots++;
-      playerWeaponStats.damage += damage;
-    }
+    });
   });
   
-  const globalWeaponStats: WeaponStats[] = Array.from(weaponStatsMap.entries()).map(([weapon, stats]) => ({
-    weapon,
-    kills: stats.kills,
-    headshots: stats.headshots,
-    damage: stats.damage,
-  }));
+  const globalWeaponStats: WeaponStats[] = Array.from(weaponStatsMap.values())
+    .sort((a, b) => b.kills - a.kills || b.damage - a.damage);
   
   // 5. KILL TIMINGS: Tempo médio até primeira kill do round
   const playerFirstKillTimes: Map<number, number[]> = new Map();
@@ -1184,12 +1369,7 @@ export const convertGoDataToAnalysisData = (goData: GoAnalysis, type: AnalysisTy
     // Weapon Stats
     const weaponStats = playerWeaponStatsMap.get(p.steamID);
     const weaponStatsArray: WeaponStats[] = weaponStats 
-      ? Array.from(weaponStats.entries()).map(([weapon, stats]) => ({
-          weapon,
-          kills: stats.kills,
-          headshots: stats.headshots,
-          damage: stats.damage,
-        }))
+      ? Array.from(weaponStats.values()).map(stats => ({ ...stats }))
       : [];
     
     // Se tiver targetPlayer com dados mais precisos, usar eles
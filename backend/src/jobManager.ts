@@ -11,6 +11,10 @@ const processingTimers = new Map<string, NodeJS.Timeout>();
 
 const stageThresholds = [20, 45, 75, 95];
 
+// Códigos de saída do processador Go que ainda gravam o JSON (parcial): 3 sinal, 4 timeout,
+// 5 demo truncada. Qualquer outro código diferente de 0 (2 = panic ou uso inválido) é falha.
+const partialExitCodes = [3, 4, 5];
+
 export const createAnalysisJob = (upload: UploadInfo, type: AnalysisType, steamId?: string): AnalysisJob => {
   const jobId = uuid();
   const now = new Date();
@@ -130,8 +134,10 @@ const finalizeJob = async (jobId: string) => {
     }
     
     // Tentar executar o processador Go
-    // Args: [demo_path, steamId?] - steamId é opcional
-    const args = [upload.path];
+    // Args: [-progress, -timeout, 270s, demo_path, steamId?] - steamId é opcional
+    // -progress faz o Go emitir o progresso real do parse (JSON por linha no stderr)
+    // O Go para sozinho antes do timeout do Node e devolve o resultado parcial
+    const args = ['-progress', '-timeout', '270s', upload.path];
     const steamId = (job as any).steamId;
     if (steamId && steamId.trim() !== '') {
       args.push(steamId);
@@ -166,13 +172,29 @@ const finalizeJob = async (jobId: string) => {
           writeStream.end();
         });
         
-        // Coletar stderr para logs
+        // Coletar stderr para logs (registros de progresso atualizam o job)
+        let stderrBuffer = '';
         childProcess.stderr.on('data', (data: Buffer) => {
-          stderrData += data.toString();
-          // Log de stderr para debug
-          const stderrStr = data.toString();
-          if (stderrStr.includes('[DEBUG]') || stderrStr.includes('[WARN]')) {
-            console.log('[Go Processor]', stderrStr.trim());
+          stderrBuffer += data.toString();
+          const lines = stderrBuffer.split('\n');
+          stderrBuffer = lines.pop() || '';
+
+          for (const line of lines) {
+            if (line.startsWith('{"type":"progress"')) {
+              try {
+                const record = JSON.parse(line);
+                job.progress = Math.max(job.progress, Math.min(99, Math.round(record.percent)));
+                job.updatedAt = new Date();
+              } catch {}
+              continue;
+            }
+
+            stderrData += line + '\n';
+            // Logs do Go (slog em texto "level=INFO ..." ou JSON {"level":"INFO",...}) e
+            // qualquer outra saída, como stack traces de panic
+            if (line.trim() !== '') {
+              console.log('[Go Processor]', line.trim());
+            }
           }
         });
         
@@ -195,6 +217,10 @@ const finalizeJob = async (jobId: string) => {
           });
           
           try {
+            if (code !== 0 && !partialExitCodes.includes(code ?? -1)) {
+              throw new Error(`código de saída ${code} não é resultado parcial`);
+            }
+
             // Ler do arquivo - verificar tamanho primeiro
             const stats = fs.statSync(tempOutputPath);
             const fileSizeMB = stats.size / (1024 * 1024);
@@ -58,15 +58,19 @@ const storage = multer.diskStorage({
   },
 });
 
+// Demos comprimidas são descomprimidas em stream pelo processador Go
+const DEMO_EXTENSIONS = ['.dem', '.dem.gz', '.dem.bz2', '.dem.zst'];
+const isDemoFileName = (name: string) => DEMO_EXTENSIONS.some((ext) => name.toLowerCase().endsWith(ext));
+
 const upload = multer({
   storage,
   limits: {
     fileSize: 450 * 1024 * 1024, // 450MB (limite máximo para arquivos .dem)
   },
   fileFilter: (_req, file, cb) => {
-    // Validar se o arquivo é .dem
-    if (!file.originalname.toLowerCase().endsWith('.dem')) {
-      return cb(new Error('Apenas arquivos .dem são permitidos.'));
+    // Validar se o arquivo é .dem (ou .dem comprimido)
+    if (!isDemoFileName(file.originalname)) {
+      return cb(new Error('Apenas arquivos .dem (ou .dem.gz/.dem.bz2/.dem.zst) são permitidos.'));
     }
     cb(null, true);
   },
@@ -94,10 +98,9 @@ app.post('/upload', authenticateToken, upload.single('demo'), (req: Authenticate
   }
 
   // Validação adicional: verificar extensão
-  const fileName = req.file.originalname.toLowerCase();
-  if (!fileName.endsWith('.dem')) {
+  if (!isDemoFileName(req.file.originalname)) {
     return res.status(400).json({ 
-      error: 'Apenas arquivos .dem são permitidos.' 
+      error: 'Apenas arquivos .dem (ou .dem.gz/.dem.bz2/.dem.zst) são permitidos.' 
     });
   }
 
@@ -266,7 +269,7 @@ app.get('/api/demo/:uploadId/frames', authenticateToken, async (req: Authenticat
       const goPath = path.resolve(__dirname, '..', 'processor', 'extract-frames.go');
       if (fs.existsSync(goPath)) {
         try {
-          execSync(`go build -o "${processorPath}" "${goPath}"`, { cwd: path.dirname(goPath) });
+          execSync(`go build -o "${processorPath}" extract-frames.go participants.go`, { cwd: path.dirname(goPath) });
         } catch (err) {
           return res.status(500).json({ error: 'Erro ao compilar extract-frames. Certifique-se de que Go está instalado.' });
         }
@@ -52,6 +52,10 @@ export interface WeaponStats {
   kills: number;
   headshots: number;
   damage: number;
+  shots?: number;     // Disparos
+  hits?: number;      // Disparos que acertaram inimigo
+  accuracy?: number;  // hits / shots em %
+  deaths?: number;    // Mortes sofridas para a arma
 }
 
 export interface EntryFrag {